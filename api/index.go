@@ -1,12 +1,13 @@
 package handler
 
 import (
+	"log"
 	"net/http"
+	"os"
 
 	"github.com/gclm/galaxy-bing-wallpapers/pkg/config"
 	"github.com/gclm/galaxy-bing-wallpapers/pkg/database"
-	"github.com/gclm/galaxy-bing-wallpapers/pkg/handler"
-	"github.com/gclm/galaxy-bing-wallpapers/pkg/middleware"
+	"github.com/gclm/galaxy-bing-wallpapers/pkg/router"
 	"github.com/gin-gonic/gin"
 )
 
@@ -24,34 +25,22 @@ func init() {
 	// 初始化 Gin
 	gin.SetMode(cfg.GinMode)
 	app = gin.New()
-	app.Use(middleware.CorsMiddleware())
-	app.Use(middleware.Recovery())
 
 	// 初始化数据库
 	if err := database.InitMongoDB(); err != nil {
 		panic(err)
 	}
 
+	// 创建缩略图缓存目录，就绪检查只检查目录状态
+	if err := os.MkdirAll(cfg.ThumbnailDir, 0o755); err != nil {
+		log.Printf("Warning: failed to create thumbnail directory: %v", err)
+	}
+
 	// 注册路由
-	setupRoutes(app)
+	router.Setup(app)
 }
 
 // Handler Vercel serverless function handler
 func Handler(w http.ResponseWriter, r *http.Request) {
 	app.ServeHTTP(w, r)
 }
-
-// setupRoutes 设置路由
-func setupRoutes(r *gin.Engine) {
-	// 根路径信息
-	r.GET("/", handler.GetInfo)
-
-	v1 := r.Group("/api/v1")
-	{
-		v1.GET("/today", handler.GetTodayWallpaper)
-		v1.GET("/random", handler.GetRandomWallpaper)
-		v1.GET("/list", middleware.TokenAuth(), handler.GetWallpaperList)
-		v1.GET("/date/:date", middleware.TokenAuth(), handler.GetWallpaperByDate)
-		v1.GET("/health", handler.HealthCheck)
-	}
-}
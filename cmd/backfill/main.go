@@ -0,0 +1,159 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gclm/galaxy-bing-wallpapers/pkg/config"
+	"github.com/gclm/galaxy-bing-wallpapers/pkg/database"
+	"github.com/gclm/galaxy-bing-wallpapers/pkg/imaging"
+	"github.com/gclm/galaxy-bing-wallpapers/pkg/model"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// group 同一张图片（OHR 标识相同）的壁纸，只下载分析一次
+type group struct {
+	key        string
+	wallpapers []model.Wallpaper
+}
+
+func main() {
+	markets := flag.String("market", "", "只处理指定市场，逗号分隔，默认处理全部")
+	limit := flag.Int("limit", 0, "最多处理的图片数量，0 表示不限制")
+	workers := flag.Int("workers", 4, "并发下载的数量")
+	force := flag.Bool("force", false, "重新计算已有特征的壁纸")
+	flag.Parse()
+
+	// 加载配置
+	if _, err := config.LoadConfig(); err != nil {
+		log.Fatalf("Failed to load config: %v", err)
+	}
+
+	// 初始化数据库连接
+	if err := database.InitMongoDB(); err != nil {
+		log.Fatalf("Failed to connect to MongoDB: %v", err)
+	}
+
+	ctx := context.Background()
+
+	filter := bson.M{}
+	if !*force {
+		filter["$or"] = missingFeatures()
+	}
+	var selected []string
+	for _, mkt := range strings.Split(*markets, ",") {
+		if mkt = strings.TrimSpace(mkt); mkt != "" {
+			selected = append(selected, mkt)
+		}
+	}
+	if len(selected) > 0 {
+		filter["mkt"] = bson.M{"$in": selected}
+	}
+
+	// 最新的壁纸优先
+	wallpapers, err := database.ListWallpapers(ctx, filter, bson.D{{Key: "datetime", Value: -1}, {Key: "id", Value: 1}})
+	if err != nil {
+		log.Fatalf("Failed to load wallpapers: %v", err)
+	}
+	groups := groupByImage(wallpapers)
+	if *limit > 0 && len(groups) > *limit {
+		groups = groups[:*limit]
+	}
+	log.Printf("%d wallpapers need image features, %d distinct images to process", len(wallpapers), len(groups))
+
+	var processed, updated, failed int64
+	jobs := make(chan group)
+	var wg sync.WaitGroup
+	for i := 0; i < max(*workers, 1); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for g := range jobs {
+				n, err := process(ctx, g)
+				if err != nil {
+					atomic.AddInt64(&failed, 1)
+					log.Printf("Failed to process %s: %v", g.key, err)
+				} else {
+					atomic.AddInt64(&updated, n)
+				}
+				if done := atomic.AddInt64(&processed, 1); done%50 == 0 {
+					log.Printf("Processed %d/%d images", done, len(groups))
+				}
+			}
+		}()
+	}
+	for _, g := range groups {
+		jobs <- g
+	}
+	close(jobs)
+	wg.Wait()
+
+	log.Printf("Done: %d images processed, %d wallpapers updated, %d images failed", processed, updated, failed)
+}
+
+// missingFeatures 缺少任一图片特征的条件
+func missingFeatures() bson.A {
+	return bson.A{
+		bson.M{"palette": bson.M{"$exists": false}},
+		bson.M{"hashes": bson.M{"$exists": false}},
+		bson.M{"blurhash": bson.M{"$exists": false}},
+	}
+}
+
+// features 壁纸中由图片计算的字段
+func features(w model.Wallpaper) bson.M {
+	return bson.M{
+		"color":    w.Color,
+		"palette":  w.Palette,
+		"hashes":   w.Hashes,
+		"blurhash": w.BlurHash,
+		"lqip":     w.LQIP,
+	}
+}
+
+// groupByImage 按 OHR 标识分组，保持输入顺序；没有标识的壁纸各自一组
+func groupByImage(wallpapers []model.Wallpaper) []group {
+	var groups []group
+	index := make(map[string]int)
+	for _, w := range wallpapers {
+		key := w.OHR
+		if key == "" {
+			key = "id:" + strconv.Itoa(w.ID)
+		}
+		i, ok := index[key]
+		if !ok {
+			i = len(groups)
+			index[key] = i
+			groups = append(groups, group{key: key})
+		}
+		groups[i].wallpapers = append(groups[i].wallpapers, w)
+	}
+	return groups
+}
+
+// process 下载一张图片并把特征写入组内的全部壁纸，某个市场的地址失效时尝试下一个
+func process(ctx context.Context, g group) (int64, error) {
+	var lastErr error
+	for _, w := range g.wallpapers {
+		downloadCtx, cancel := context.WithTimeout(ctx, 2*time.Minute)
+		err := imaging.AnnotateURL(downloadCtx, &w)
+		cancel()
+		if err != nil {
+			lastErr = err
+			continue
+		}
+
+		ids := make([]int, len(g.wallpapers))
+		for i, other := range g.wallpapers {
+			ids[i] = other.ID
+		}
+		return database.UpdateWallpapers(ctx, bson.M{"id": bson.M{"$in": ids}}, bson.M{"$set": features(w)})
+	}
+	return 0, lastErr
+}
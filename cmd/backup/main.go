@@ -0,0 +1,54 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/gclm/galaxy-bing-wallpapers/pkg/config"
+	"github.com/gclm/galaxy-bing-wallpapers/pkg/database"
+	"github.com/gclm/galaxy-bing-wallpapers/pkg/snapshot"
+)
+
+func main() {
+	dir := flag.String("dir", "backups", "快照根目录")
+	images := flag.Bool("images", false, "同时镜像壁纸图片，复用上一个快照中已有的图片")
+	keep := flag.Int("keep", 0, "最多保留的快照数量，0 表示不限制")
+	maxAge := flag.Duration("max-age", 0, "删除早于此时长的快照，如 720h，0 表示不限制")
+	flag.Parse()
+
+	// 加载配置
+	if _, err := config.LoadConfig(); err != nil {
+		log.Fatalf("Failed to load config: %v", err)
+	}
+
+	// 初始化数据库连接
+	if err := database.InitMongoDB(); err != nil {
+		log.Fatalf("Failed to connect to MongoDB: %v", err)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	m, path, err := snapshot.Create(ctx, snapshot.Options{Dir: *dir, Images: *images})
+	if err != nil {
+		log.Fatalf("Failed to create snapshot: %v", err)
+	}
+
+	// 写入后立即校验一次
+	if err := snapshot.Verify(path, m); err != nil {
+		log.Fatalf("Snapshot %s failed verification: %v", path, err)
+	}
+	log.Printf("Created snapshot %s (%d files, %d images)", path, len(m.Files), len(m.Images))
+
+	removed, err := snapshot.Prune(*dir, *keep, *maxAge)
+	for _, p := range removed {
+		log.Printf("Removed old snapshot %s", p)
+	}
+	if err != nil {
+		log.Fatalf("Failed to prune snapshots: %v", err)
+	}
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+
+	"github.com/gclm/galaxy-bing-wallpapers/pkg/client"
+	"github.com/gclm/galaxy-bing-wallpapers/pkg/model"
+)
+
+func todayCommand() command {
+	fs := flag.NewFlagSet("today", flag.ExitOnError)
+	mkt := fs.String("mkt", "zh-CN", "地区代码")
+	res := fs.String("res", "1920x1080", "分辨率")
+
+	return command{flags: fs, run: func(ctx context.Context, c *client.Client, _ []string) error {
+		imageOpts, err := imageOptions(*mkt, *res)
+		if err != nil {
+			return err
+		}
+		image, err := c.Today(ctx, imageOpts)
+		if err != nil {
+			return err
+		}
+		return printImage(image)
+	}}
+}
+
+func randomCommand() command {
+	fs := flag.NewFlagSet("random", flag.ExitOnError)
+	res := fs.String("res", "1920x1080", "分辨率")
+
+	return command{flags: fs, run: func(ctx context.Context, c *client.Client, _ []string) error {
+		imageOpts, err := imageOptions("", *res)
+		if err != nil {
+			return err
+		}
+		image, err := c.Random(ctx, imageOpts)
+		if err != nil {
+			return err
+		}
+		return printImage(image)
+	}}
+}
+
+func showCommand() command {
+	fs := flag.NewFlagSet("show", flag.ExitOnError)
+	mkt := fs.String("mkt", "", "地区代码")
+	res := fs.String("res", "1920x1080", "分辨率")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "用法: bingwall show [flags] <YYYY-MM-DD>")
+		fs.PrintDefaults()
+	}
+
+	return command{flags: fs, run: func(ctx context.Context, c *client.Client, args []string) error {
+		if len(args) != 1 {
+			fs.Usage()
+			return fmt.Errorf("show requires exactly one date argument")
+		}
+		imageOpts, err := imageOptions(*mkt, *res)
+		if err != nil {
+			return err
+		}
+		image, err := c.ByDate(ctx, args[0], imageOpts)
+		if err != nil {
+			return err
+		}
+		return printImage(image)
+	}}
+}
+
+func listCommand() command {
+	fs := flag.NewFlagSet("list", flag.ExitOnError)
+	mkt := fs.String("mkt", "", "地区代码")
+	from := fs.String("from", "", "起始日期（包含），YYYY-MM-DD")
+	to := fs.String("to", "", "结束日期（包含），YYYY-MM-DD")
+	page := fs.Int("page", 1, "页码")
+	pageSize := fs.Int("page-size", 20, "每页数量")
+	all := fs.Bool("all", false, "列出所有页")
+
+	return command{flags: fs, run: func(ctx context.Context, c *client.Client, _ []string) error {
+		listOpts := client.ListOptions{
+			Market:   *mkt,
+			From:     *from,
+			To:       *to,
+			Page:     *page,
+			PageSize: *pageSize,
+		}
+
+		if !*all {
+			result, err := c.List(ctx, listOpts)
+			if err != nil {
+				return err
+			}
+			return printWallpapers(result.Wallpapers, result.Total)
+		}
+
+		var wallpapers []model.WallpaperItem
+		it := c.ListAll(ctx, listOpts)
+		for it.Next() {
+			wallpapers = append(wallpapers, it.Wallpaper())
+		}
+		if err := it.Err(); err != nil {
+			return err
+		}
+		return printWallpapers(wallpapers, it.Total())
+	}}
+}
+
+// imageOptions 根据命令行参数构建图片查询参数
+func imageOptions(mkt, res string) (client.ImageOptions, error) {
+	width, height, err := client.ParseResolution(res)
+	if err != nil {
+		return client.ImageOptions{}, err
+	}
+	return client.ImageOptions{Market: mkt, Width: width, Height: height}, nil
+}
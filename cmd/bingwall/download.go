@@ -0,0 +1,242 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"sync"
+
+	"github.com/gclm/galaxy-bing-wallpapers/pkg/client"
+)
+
+// manifestName 下载目录中记录已下载文件大小和哈希的清单
+const manifestName = ".bingwall-manifest.json"
+
+// manifestEntry 清单中的单个文件
+type manifestEntry struct {
+	URL    string `json:"url"`
+	Size   int64  `json:"size"`
+	SHA256 string `json:"sha256"`
+}
+
+// manifest 下载清单，用于断点续传时跳过已完整下载的文件
+type manifest struct {
+	mu    sync.Mutex
+	path  string
+	Files map[string]manifestEntry `json:"files"`
+}
+
+// downloadTask 单个下载任务
+type downloadTask struct {
+	url  string
+	name string
+}
+
+// downloadReport 下载结果统计
+type downloadReport struct {
+	Downloaded []string          `json:"downloaded"`
+	Skipped    []string          `json:"skipped"`
+	Failed     map[string]string `json:"failed"`
+}
+
+func downloadCommand() command {
+	fs := flag.NewFlagSet("download", flag.ExitOnError)
+	mkt := fs.String("mkt", "", "地区代码，为空时下载所有市场")
+	from := fs.String("from", "", "起始日期（包含），YYYY-MM-DD")
+	to := fs.String("to", "", "结束日期（包含），YYYY-MM-DD")
+	res := fs.String("res", "1920x1080", "分辨率")
+	out := fs.String("out", ".", "保存目录")
+	parallel := fs.Int("parallel", 4, "并发下载数")
+
+	return command{flags: fs, run: func(ctx context.Context, c *client.Client, _ []string) error {
+		width, height, err := client.ParseResolution(*res)
+		if err != nil {
+			return err
+		}
+		if *parallel < 1 {
+			*parallel = 1
+		}
+		if err := os.MkdirAll(*out, 0o755); err != nil {
+			return fmt.Errorf("failed to create output directory: %v", err)
+		}
+
+		m, err := loadManifest(filepath.Join(*out, manifestName))
+		if err != nil {
+			return err
+		}
+
+		// 收集下载任务
+		w, h := strconv.Itoa(width), strconv.Itoa(height)
+		var tasks []downloadTask
+		it := c.ListAll(ctx, client.ListOptions{Market: *mkt, From: *from, To: *to, PageSize: 100})
+		for it.Next() {
+			wallpaper := it.Wallpaper()
+			tasks = append(tasks, downloadTask{
+				url:  wallpaper.GenerateImageURL(w, h),
+				name: fmt.Sprintf("%s_%s_%sx%s.jpg", wallpaper.Datetime, wallpaper.Mkt, w, h),
+			})
+		}
+		if err := it.Err(); err != nil {
+			return err
+		}
+
+		report := runDownloads(ctx, c, m, *out, tasks, *parallel)
+		if err := m.save(); err != nil {
+			return err
+		}
+
+		if opts.json {
+			if err := printJSON(report); err != nil {
+				return err
+			}
+		} else {
+			fmt.Printf("\n下载 %d 个，跳过 %d 个，失败 %d 个\n", len(report.Downloaded), len(report.Skipped), len(report.Failed))
+		}
+
+		if len(report.Failed) > 0 {
+			return fmt.Errorf("%d downloads failed", len(report.Failed))
+		}
+		return nil
+	}}
+}
+
+// runDownloads 并发执行下载任务
+func runDownloads(ctx context.Context, c *client.Client, m *manifest, dir string, tasks []downloadTask, parallel int) *downloadReport {
+	report := &downloadReport{
+		Downloaded: []string{},
+		Skipped:    []string{},
+		Failed:     make(map[string]string),
+	}
+	var mu sync.Mutex
+
+	queue := make(chan downloadTask)
+	var wg sync.WaitGroup
+	for i := 0; i < parallel; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for task := range queue {
+				skipped, err := downloadOne(ctx, c, m, dir, task)
+
+				mu.Lock()
+				switch {
+				case err != nil:
+					report.Failed[task.name] = err.Error()
+					logProgress("✗ %s: %v", task.name, err)
+				case skipped:
+					report.Skipped = append(report.Skipped, task.name)
+					logProgress("- %s 已存在，跳过", task.name)
+				default:
+					report.Downloaded = append(report.Downloaded, task.name)
+					logProgress("✓ %s", task.name)
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+
+	for _, task := range tasks {
+		if ctx.Err() != nil {
+			break
+		}
+		queue <- task
+	}
+	close(queue)
+	wg.Wait()
+
+	sort.Strings(report.Downloaded)
+	sort.Strings(report.Skipped)
+	return report
+}
+
+// downloadOne 下载单个文件，本地文件已完整时返回 true
+// 本地文件与清单记录的大小和哈希一致，或与远程文件大小一致时视为完整
+func downloadOne(ctx context.Context, c *client.Client, m *manifest, dir string, task downloadTask) (bool, error) {
+	path := filepath.Join(dir, task.name)
+
+	if info, err := os.Stat(path); err == nil {
+		if entry, ok := m.get(task.name); ok && entry.Size == info.Size() {
+			if sum, err := client.FileSHA256(path); err == nil && sum == entry.SHA256 {
+				return true, nil
+			}
+		}
+
+		if size, err := c.RemoteSize(ctx, task.url); err == nil && size == info.Size() {
+			sum, err := client.FileSHA256(path)
+			if err != nil {
+				return false, err
+			}
+			m.set(task.name, manifestEntry{URL: task.url, Size: size, SHA256: sum})
+			return true, nil
+		}
+	}
+
+	result, err := c.Download(ctx, task.url, path)
+	if err != nil {
+		return false, err
+	}
+	m.set(task.name, manifestEntry{URL: task.url, Size: result.Size, SHA256: result.SHA256})
+	return false, nil
+}
+
+// logProgress 输出下载进度，JSON 模式下写到标准错误
+func logProgress(format string, args ...interface{}) {
+	output := os.Stdout
+	if opts.json {
+		output = os.Stderr
+	}
+	fmt.Fprintf(output, format+"\n", args...)
+}
+
+// loadManifest 读取下载清单，不存在时返回空清单
+func loadManifest(path string) (*manifest, error) {
+	m := &manifest{path: path, Files: make(map[string]manifestEntry)}
+
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return m, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read manifest: %v", err)
+	}
+	if err := json.Unmarshal(data, m); err != nil {
+		return nil, fmt.Errorf("failed to parse manifest %s: %v", path, err)
+	}
+	if m.Files == nil {
+		m.Files = make(map[string]manifestEntry)
+	}
+	return m, nil
+}
+
+func (m *manifest) get(name string) (manifestEntry, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	entry, ok := m.Files[name]
+	return entry, ok
+}
+
+func (m *manifest) set(name string, entry manifestEntry) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.Files[name] = entry
+}
+
+// save 写回下载清单
+func (m *manifest) save() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode manifest: %v", err)
+	}
+	if err := os.WriteFile(m.path, data, 0o644); err != nil {
+		return fmt.Errorf("failed to write manifest: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+
+	"github.com/gclm/galaxy-bing-wallpapers/pkg/client"
+)
+
+const usage = `bingwall - 必应壁纸命令行客户端
+
+用法:
+  bingwall <command> [flags]
+
+命令:
+  today                 查看今日壁纸
+  random                随机一张壁纸
+  list                  分页查看壁纸列表（需要 token）
+  show <date>           查看指定日期的壁纸（需要 token）
+  download              批量下载壁纸（需要 token）
+
+通用参数:
+  --server URL          服务地址，默认读取 BINGWALL_SERVER，否则为 http://localhost:8080
+  --token TOKEN         API 访问令牌，默认读取 BINGWALL_TOKEN 或 API_TOKEN
+  --json                以 JSON 输出
+
+使用 "bingwall <command> -h" 查看命令参数。
+`
+
+// command 子命令
+type command struct {
+	flags *flag.FlagSet
+	run   func(ctx context.Context, c *client.Client, args []string) error
+}
+
+// globalOptions 所有子命令共用的参数
+type globalOptions struct {
+	server string
+	token  string
+	json   bool
+}
+
+var opts globalOptions
+
+func main() {
+	if len(os.Args) < 2 || os.Args[1] == "-h" || os.Args[1] == "--help" || os.Args[1] == "help" {
+		fmt.Print(usage)
+		return
+	}
+
+	commands := map[string]command{
+		"today":    todayCommand(),
+		"random":   randomCommand(),
+		"list":     listCommand(),
+		"show":     showCommand(),
+		"download": downloadCommand(),
+	}
+
+	cmd, ok := commands[os.Args[1]]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "未知命令: %s\n\n%s", os.Args[1], usage)
+		os.Exit(2)
+	}
+
+	addGlobalFlags(cmd.flags)
+	if err := cmd.flags.Parse(os.Args[2:]); err != nil {
+		os.Exit(2)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	c := client.New(opts.server, opts.token)
+	if err := cmd.run(ctx, c, cmd.flags.Args()); err != nil {
+		fmt.Fprintf(os.Stderr, "错误: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// addGlobalFlags 为子命令添加通用参数
+func addGlobalFlags(fs *flag.FlagSet) {
+	server := os.Getenv("BINGWALL_SERVER")
+	if server == "" {
+		server = "http://localhost:8080"
+	}
+	token := os.Getenv("BINGWALL_TOKEN")
+	if token == "" {
+		token = os.Getenv("API_TOKEN")
+	}
+
+	fs.StringVar(&opts.server, "server", server, "服务地址")
+	fs.StringVar(&opts.token, "token", token, "API 访问令牌")
+	fs.BoolVar(&opts.json, "json", false, "以 JSON 输出")
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/gclm/galaxy-bing-wallpapers/pkg/model"
+)
+
+// printJSON 以缩进格式输出 JSON
+func printJSON(v interface{}) error {
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	encoder.SetEscapeHTML(false)
+	return encoder.Encode(v)
+}
+
+// printImage 输出单张壁纸信息
+func printImage(image *model.ImageResponse) error {
+	if opts.json {
+		return printJSON(image)
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "日期\t%s\n", image.Datetime)
+	fmt.Fprintf(w, "标题\t%s\n", image.Title)
+	fmt.Fprintf(w, "地址\t%s\n", image.Url)
+	return w.Flush()
+}
+
+// printWallpapers 以表格输出壁纸列表
+func printWallpapers(wallpapers []model.WallpaperItem, total int64) error {
+	if opts.json {
+		return printJSON(model.ApiResponse{
+			Code:    200,
+			Message: "success",
+			Data:    wallpapers,
+			Total:   total,
+		})
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\t日期\t市场\t标题")
+	for _, wallpaper := range wallpapers {
+		fmt.Fprintf(w, "%d\t%s\t%s\t%s\n", wallpaper.ID, wallpaper.Datetime, wallpaper.Mkt, wallpaper.Title)
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+
+	fmt.Printf("\n共 %d 条，本次显示 %d 条\n", total, len(wallpapers))
+	return nil
+}
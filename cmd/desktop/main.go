@@ -0,0 +1,222 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+	"net/url"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/gclm/galaxy-bing-wallpapers/pkg/client"
+	"github.com/gclm/galaxy-bing-wallpapers/pkg/model"
+)
+
+// unsafeFileChars 文件名中需要替换的字符
+var unsafeFileChars = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+// daemon 桌面壁纸守护进程
+type daemon struct {
+	client   *client.Client
+	setter   Setter
+	image    client.ImageOptions
+	cacheDir string
+	keep     int
+
+	lastURL string // 最近一次应用的图片地址
+}
+
+func main() {
+	server := flag.String("server", envOrDefault("BINGWALL_SERVER", "http://localhost:8080"), "服务地址")
+	mkt := flag.String("mkt", "zh-CN", "地区代码")
+	res := flag.String("res", "1920x1080", "分辨率")
+	setterName := flag.String("setter", "auto", "壁纸设置方式：auto、gnome、kde、sway、swaybg、feh、command")
+	command := flag.String("command", "", "自定义设置命令，支持 {path} 和 {uri} 占位符，如 \"nitrogen --set-zoom-fill {path}\"")
+	interval := flag.Duration("interval", time.Hour, "检查今日壁纸的间隔")
+	rotate := flag.Duration("rotate", 0, "轮换模式：按此间隔切换到随机壁纸，0 表示只使用今日壁纸")
+	cacheDir := flag.String("cache-dir", defaultCacheDir(), "图片缓存目录")
+	keep := flag.Int("keep", 30, "缓存中最多保留的图片数量，只清理本程序下载的 bingwall-*.jpg")
+	once := flag.Bool("once", false, "只设置一次后退出")
+	flag.Parse()
+
+	width, height, err := client.ParseResolution(*res)
+	if err != nil {
+		log.Fatalf("%v", err)
+	}
+
+	setter, err := newSetter(*setterName, *command)
+	if err != nil {
+		log.Fatalf("Failed to create setter: %v", err)
+	}
+
+	if err := os.MkdirAll(*cacheDir, 0o755); err != nil {
+		log.Fatalf("Failed to create cache directory: %v", err)
+	}
+
+	d := &daemon{
+		client:   client.New(*server, ""),
+		setter:   setter,
+		image:    client.ImageOptions{Market: *mkt, Width: width, Height: height},
+		cacheDir: *cacheDir,
+		keep:     *keep,
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	// 轮换模式使用随机壁纸，否则定期检查今日壁纸
+	tick, period := d.applyToday, *interval
+	if *rotate > 0 {
+		tick, period = d.applyRandom, *rotate
+	}
+
+	if err := tick(ctx); err != nil {
+		log.Printf("Failed to apply wallpaper: %v", err)
+		if *once {
+			os.Exit(1)
+		}
+	}
+	if *once {
+		return
+	}
+
+	ticker := time.NewTicker(period)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			log.Printf("Stopping desktop daemon")
+			return
+		case <-ticker.C:
+			if err := tick(ctx); err != nil {
+				log.Printf("Failed to apply wallpaper: %v", err)
+			}
+		}
+	}
+}
+
+// applyToday 获取今日壁纸，与上次应用的不同时下载并设置
+func (d *daemon) applyToday(ctx context.Context) error {
+	image, err := d.client.Today(ctx, d.image)
+	if err != nil {
+		if client.IsNotFound(err) {
+			log.Printf("Today's wallpaper for %s is not available yet", d.image.Market)
+			return nil
+		}
+		return err
+	}
+
+	if image.Url == d.lastURL {
+		return nil
+	}
+	return d.apply(ctx, image)
+}
+
+// applyRandom 获取随机壁纸并设置
+func (d *daemon) applyRandom(ctx context.Context) error {
+	image, err := d.client.Random(ctx, d.image)
+	if err != nil {
+		return err
+	}
+	return d.apply(ctx, image)
+}
+
+// apply 下载图片到缓存目录并设置为桌面壁纸
+func (d *daemon) apply(ctx context.Context, image *model.ImageResponse) error {
+	path := filepath.Join(d.cacheDir, cacheFileName(image))
+
+	if _, err := os.Stat(path); err != nil {
+		if _, err := d.client.Download(ctx, image.Url, path); err != nil {
+			return err
+		}
+	} else {
+		// 复用缓存时更新修改时间，避免被当作旧图片清理
+		now := time.Now()
+		_ = os.Chtimes(path, now, now)
+	}
+
+	if err := d.setter.Set(ctx, path); err != nil {
+		return err
+	}
+
+	d.lastURL = image.Url
+	log.Printf("Applied wallpaper %s: %s", image.Datetime, image.Title)
+
+	d.pruneCache()
+	return nil
+}
+
+// pruneCache 只保留最近修改的 keep 张图片，只清理本程序下载的文件（以 cachePrefix 开头），目录中的其他图片不受影响
+func (d *daemon) pruneCache() {
+	if d.keep <= 0 {
+		return
+	}
+
+	entries, err := os.ReadDir(d.cacheDir)
+	if err != nil {
+		return
+	}
+
+	type cached struct {
+		path    string
+		modTime time.Time
+	}
+	var files []cached
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasPrefix(entry.Name(), cachePrefix) || !strings.HasSuffix(entry.Name(), ".jpg") {
+			continue
+		}
+		if info, err := entry.Info(); err == nil {
+			files = append(files, cached{filepath.Join(d.cacheDir, entry.Name()), info.ModTime()})
+		}
+	}
+
+	sort.Slice(files, func(i, j int) bool {
+		return files[i].modTime.After(files[j].modTime)
+	})
+	for i := d.keep; i < len(files); i++ {
+		_ = os.Remove(files[i].path)
+	}
+}
+
+// cachePrefix 缓存文件名的前缀，用于识别本程序下载的图片
+const cachePrefix = "bingwall-"
+
+// cacheFileName 根据图片地址生成缓存文件名，如 bingwall-OHR.IceHoleOtter_ZH-CN0106321041_1920x1080.jpg
+func cacheFileName(image *model.ImageResponse) string {
+	name := image.Datetime
+	if u, err := url.Parse(image.Url); err == nil {
+		if id := u.Query().Get("id"); id != "" {
+			name = id
+		} else if base := filepath.Base(u.Path); base != "." && base != "/" {
+			name = base
+		}
+	}
+
+	name = cachePrefix + unsafeFileChars.ReplaceAllString(name, "_")
+	if !strings.HasSuffix(name, ".jpg") {
+		name += ".jpg"
+	}
+	return name
+}
+
+// defaultCacheDir 默认缓存目录，如 ~/.cache/bingwall
+func defaultCacheDir() string {
+	if dir, err := os.UserCacheDir(); err == nil {
+		return filepath.Join(dir, "bingwall")
+	}
+	return filepath.Join(os.TempDir(), "bingwall")
+}
+
+func envOrDefault(key, defaultValue string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return defaultValue
+}
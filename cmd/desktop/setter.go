@@ -0,0 +1,125 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+)
+
+// Setter 设置桌面壁纸
+type Setter interface {
+	Set(ctx context.Context, path string) error
+}
+
+// newSetter 根据名称创建壁纸设置器，template 仅在 command 模式下使用
+func newSetter(name, template string) (Setter, error) {
+	if name == "auto" {
+		name = detectSetter()
+	}
+
+	switch name {
+	case "gnome":
+		return gnomeSetter{}, nil
+	case "kde":
+		return commandSetter{args: []string{"plasma-apply-wallpaperimage", "{path}"}}, nil
+	case "sway":
+		return commandSetter{args: []string{"swaymsg", "output", "*", "bg", "{path}", "fill"}}, nil
+	case "swaybg":
+		return &swaybgSetter{}, nil
+	case "feh":
+		return commandSetter{args: []string{"feh", "--no-fehbg", "--bg-fill", "{path}"}}, nil
+	case "command":
+		args := strings.Fields(template)
+		if len(args) == 0 {
+			return nil, fmt.Errorf("--command is required when --setter=command")
+		}
+		return commandSetter{args: args}, nil
+	default:
+		return nil, fmt.Errorf("unsupported setter %q, use auto, gnome, kde, sway, swaybg, feh or command", name)
+	}
+}
+
+// detectSetter 根据桌面环境变量推断设置器
+func detectSetter() string {
+	desktop := strings.ToLower(os.Getenv("XDG_CURRENT_DESKTOP"))
+	switch {
+	case os.Getenv("SWAYSOCK") != "":
+		return "sway"
+	case strings.Contains(desktop, "gnome"), strings.Contains(desktop, "unity"), strings.Contains(desktop, "budgie"):
+		return "gnome"
+	case strings.Contains(desktop, "kde"):
+		return "kde"
+	default:
+		return "feh"
+	}
+}
+
+// expandArgs 替换命令参数中的 {path} 和 {uri} 占位符
+func expandArgs(args []string, path string) []string {
+	uri := (&url.URL{Scheme: "file", Path: path}).String()
+	expanded := make([]string, len(args))
+	for i, arg := range args {
+		arg = strings.ReplaceAll(arg, "{path}", path)
+		expanded[i] = strings.ReplaceAll(arg, "{uri}", uri)
+	}
+	return expanded
+}
+
+// runCommand 执行命令并在失败时附带输出
+func runCommand(ctx context.Context, args []string) error {
+	output, err := exec.CommandContext(ctx, args[0], args[1:]...).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("%s failed: %v: %s", args[0], err, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
+// commandSetter 通过执行一条命令设置壁纸
+type commandSetter struct {
+	args []string
+}
+
+func (s commandSetter) Set(ctx context.Context, path string) error {
+	return runCommand(ctx, expandArgs(s.args, path))
+}
+
+// gnomeSetter 通过 gsettings 设置 GNOME 壁纸，同时设置深色模式壁纸
+type gnomeSetter struct{}
+
+func (gnomeSetter) Set(ctx context.Context, path string) error {
+	uri := (&url.URL{Scheme: "file", Path: path}).String()
+	if err := runCommand(ctx, []string{"gsettings", "set", "org.gnome.desktop.background", "picture-uri", uri}); err != nil {
+		return err
+	}
+	// GNOME 42 之前没有 picture-uri-dark，忽略错误
+	_ = runCommand(ctx, []string{"gsettings", "set", "org.gnome.desktop.background", "picture-uri-dark", uri})
+	return nil
+}
+
+// swaybgSetter 启动常驻的 swaybg 进程，设置新壁纸时替换旧进程
+type swaybgSetter struct {
+	mu  sync.Mutex
+	cmd *exec.Cmd
+}
+
+func (s *swaybgSetter) Set(_ context.Context, path string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	cmd := exec.Command("swaybg", "-i", path, "-m", "fill")
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("swaybg failed: %v", err)
+	}
+
+	// 新进程启动后再结束旧进程，避免出现空白背景
+	if s.cmd != nil && s.cmd.Process != nil {
+		_ = s.cmd.Process.Kill()
+		_ = s.cmd.Wait()
+	}
+	s.cmd = cmd
+	return nil
+}
@@ -0,0 +1,295 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/gclm/galaxy-bing-wallpapers/pkg/model"
+	"github.com/gclm/galaxy-bing-wallpapers/pkg/utils"
+)
+
+// 问题类型
+const (
+	kindGap                = "gap"
+	kindDuplicateID        = "duplicate_id"
+	kindDuplicateHash      = "duplicate_hash"
+	kindDuplicateDate      = "duplicate_date"
+	kindMalformedURL       = "malformed_url"
+	kindInvalidDate        = "invalid_date"
+	kindInvalidCreatedTime = "invalid_created_time"
+	kindInvalidMarket      = "invalid_market"
+	kindArchiveMissing     = "archive_missing"
+	kindArchiveMismatch    = "archive_mismatch"
+	kindStartDate          = "startdate_mismatch"
+)
+
+// kinds 按报告输出顺序排列的问题类型
+var kinds = []string{
+	kindGap,
+	kindDuplicateID,
+	kindDuplicateHash,
+	kindDuplicateDate,
+	kindMalformedURL,
+	kindInvalidDate,
+	kindInvalidCreatedTime,
+	kindInvalidMarket,
+	kindArchiveMissing,
+	kindArchiveMismatch,
+	kindStartDate,
+}
+
+const dateLayout = "2006-01-02"
+
+// createdTimeLayouts created_time 可接受的格式
+var createdTimeLayouts = []string{dateLayout, "2006-01-02 15:04:05", time.RFC3339}
+
+// issue 检查发现的单个问题
+type issue struct {
+	Kind     string           `json:"kind"`
+	Market   string           `json:"market,omitempty"`
+	Datetime string           `json:"datetime,omitempty"`
+	ID       int              `json:"id,omitempty"`
+	Detail   string           `json:"detail"`
+	Fixable  bool             `json:"fixable"`
+	record   *model.Wallpaper // 需要修复的记录
+	target   string           // startdate_mismatch 应改为的日期
+}
+
+// checkRecords 检查单条记录的字段
+func checkRecords(wallpapers []model.Wallpaper) []issue {
+	var issues []issue
+	for i := range wallpapers {
+		w := &wallpapers[i]
+
+		if !model.IsValidMarket(w.Mkt) {
+			issues = append(issues, issue{Kind: kindInvalidMarket, Market: w.Mkt, Datetime: w.Datetime, ID: w.ID,
+				Detail: fmt.Sprintf("unsupported market %q", w.Mkt)})
+		}
+
+		if !w.HasResolutionSuffix() {
+			_, fixable := repairURL(w.Url)
+			issues = append(issues, issue{Kind: kindMalformedURL, Market: w.Mkt, Datetime: w.Datetime, ID: w.ID,
+				Detail: w.Url, Fixable: fixable, record: w})
+		}
+
+		date, err := time.Parse(dateLayout, w.Datetime)
+		if err != nil {
+			issues = append(issues, issue{Kind: kindInvalidDate, Market: w.Mkt, Datetime: w.Datetime, ID: w.ID,
+				Detail: fmt.Sprintf("datetime %q is not YYYY-MM-DD", w.Datetime)})
+			continue
+		}
+
+		created, ok := parseCreatedTime(w.CreatedTime)
+		switch {
+		case !ok:
+			issues = append(issues, issue{Kind: kindInvalidCreatedTime, Market: w.Mkt, Datetime: w.Datetime, ID: w.ID,
+				Detail: fmt.Sprintf("created_time %q is not a valid time", w.CreatedTime), Fixable: true, record: w})
+		case created.Before(date):
+			issues = append(issues, issue{Kind: kindInvalidCreatedTime, Market: w.Mkt, Datetime: w.Datetime, ID: w.ID,
+				Detail: fmt.Sprintf("created_time %s is before datetime", w.CreatedTime), Fixable: true, record: w})
+		}
+	}
+	return issues
+}
+
+// checkDuplicates 检查重复的 ID、同一市场内重复的日期和哈希
+func checkDuplicates(wallpapers []model.Wallpaper) []issue {
+	byID := make(map[int][]*model.Wallpaper)
+	byDate := make(map[string][]*model.Wallpaper)
+	byHash := make(map[string][]*model.Wallpaper)
+
+	for i := range wallpapers {
+		w := &wallpapers[i]
+		byID[w.ID] = append(byID[w.ID], w)
+		byDate[w.Mkt+"/"+w.Datetime] = append(byDate[w.Mkt+"/"+w.Datetime], w)
+		if w.Hsh != "" {
+			byHash[w.Mkt+"/"+w.Hsh] = append(byHash[w.Mkt+"/"+w.Hsh], w)
+		}
+	}
+
+	var issues []issue
+	for _, id := range sortedKeys(byID) {
+		group := byID[id]
+		// 第一条保留原 ID，其余记录需要重新分配
+		for _, w := range group[1:] {
+			issues = append(issues, issue{Kind: kindDuplicateID, Market: w.Mkt, Datetime: w.Datetime, ID: w.ID,
+				Detail: fmt.Sprintf("id %d is shared by %d records", id, len(group)), Fixable: true, record: w})
+		}
+	}
+	for _, key := range sortedKeys(byDate) {
+		if group := byDate[key]; len(group) > 1 {
+			w := group[0]
+			issues = append(issues, issue{Kind: kindDuplicateDate, Market: w.Mkt, Datetime: w.Datetime,
+				Detail: fmt.Sprintf("%d records share this date, ids %s", len(group), joinIDs(group))})
+		}
+	}
+	for _, key := range sortedKeys(byHash) {
+		if group := byHash[key]; len(group) > 1 {
+			w := group[0]
+			issues = append(issues, issue{Kind: kindDuplicateHash, Market: w.Mkt, Datetime: w.Datetime,
+				Detail: fmt.Sprintf("hsh %s is shared by ids %s", w.Hsh, joinIDs(group))})
+		}
+	}
+	return issues
+}
+
+// checkGaps 检查每个市场从第一天到最后一天之间缺失的日期
+func checkGaps(wallpapers []model.Wallpaper) []issue {
+	dates := make(map[string]map[string]bool)
+	for _, w := range wallpapers {
+		if _, err := time.Parse(dateLayout, w.Datetime); err != nil {
+			continue
+		}
+		if dates[w.Mkt] == nil {
+			dates[w.Mkt] = make(map[string]bool)
+		}
+		dates[w.Mkt][w.Datetime] = true
+	}
+
+	var issues []issue
+	for _, mkt := range sortedKeys(dates) {
+		days := sortedKeys(dates[mkt])
+		first, _ := time.Parse(dateLayout, days[0])
+		last, _ := time.Parse(dateLayout, days[len(days)-1])
+
+		for day := first; !day.After(last); day = day.AddDate(0, 0, 1) {
+			date := day.Format(dateLayout)
+			if !dates[mkt][date] {
+				issues = append(issues, issue{Kind: kindGap, Market: mkt, Datetime: date, Detail: "no wallpaper for this day"})
+			}
+		}
+	}
+	return issues
+}
+
+// checkArchive 与 data/<mkt>_all.json 对比：存档中有而数据库中没有的记录，以及内容不一致的记录
+func checkArchive(wallpapers []model.Wallpaper, archives map[string][]model.Wallpaper) []issue {
+	stored := make(map[string]*model.Wallpaper)
+	for i := range wallpapers {
+		w := &wallpapers[i]
+		stored[w.Mkt+"/"+w.Datetime] = w
+	}
+
+	var issues []issue
+	for _, mkt := range sortedKeys(archives) {
+		records := archives[mkt]
+		for i := range records {
+			a := &records[i]
+			s, ok := stored[mkt+"/"+a.Datetime]
+			if !ok {
+				issues = append(issues, issue{Kind: kindArchiveMissing, Market: mkt, Datetime: a.Datetime,
+					Detail: fmt.Sprintf("%q is in the archive but not in the store", a.Title), Fixable: true, record: a})
+				continue
+			}
+
+			var fields []string
+			if s.Title != a.Title {
+				fields = append(fields, "title")
+			}
+			if s.Url != a.Url {
+				fields = append(fields, "url")
+			}
+			if s.Copyright != a.Copyright {
+				fields = append(fields, "copyright")
+			}
+			if s.Hsh != a.Hsh {
+				fields = append(fields, "hsh")
+			}
+			if len(fields) > 0 {
+				issues = append(issues, issue{Kind: kindArchiveMismatch, Market: mkt, Datetime: a.Datetime, ID: s.ID,
+					Detail: fmt.Sprintf("fields differ from archive: %v", fields)})
+			}
+		}
+	}
+	return issues
+}
+
+// checkStartDates 与必应接口最近的壁纸对比 datetime 和 startdate
+// 抓取任务以前按服务器的日期保存，与市场当地的 startdate 可能相差一天
+// 修复按返回的顺序逐条执行，移动每条记录前它的目标日期已经空出
+func checkStartDates(wallpapers []model.Wallpaper, recent map[string][]utils.BingImage) []issue {
+	byImage := make(map[string]*model.Wallpaper)
+	dates := make(map[string]*model.Wallpaper)
+	for i := range wallpapers {
+		w := &wallpapers[i]
+		if ohr := model.ParseOHR(w.Url); ohr != "" {
+			byImage[w.Mkt+"/"+ohr] = w
+		}
+		dates[w.Mkt+"/"+w.Datetime] = w
+	}
+
+	var issues []issue
+	for _, mkt := range sortedKeys(recent) {
+		for _, image := range recent[mkt] {
+			date, ok := image.Date()
+			ohr := model.ParseOHR(image.URL)
+			if !ok || ohr == "" {
+				continue
+			}
+			w, ok := byImage[mkt+"/"+ohr]
+			if !ok || w.Datetime == date {
+				continue
+			}
+			issues = append(issues, issue{Kind: kindStartDate, Market: mkt, Datetime: w.Datetime, ID: w.ID,
+				Detail: fmt.Sprintf("bing startdate of this image is %s", date), record: w, target: date})
+		}
+	}
+
+	// 按修复顺序模拟：目标日期空闲的先移动，空出的日期再留给后面的记录；互相占用的记录无法逐条修复
+	var ordered []issue
+	for progress := true; progress; {
+		progress = false
+		for i := range issues {
+			is := &issues[i]
+			if is.Fixable {
+				continue
+			}
+			if _, taken := dates[is.Market+"/"+is.target]; taken {
+				continue
+			}
+			is.Fixable = true
+			progress = true
+			delete(dates, is.Market+"/"+is.Datetime)
+			dates[is.Market+"/"+is.target] = is.record
+			ordered = append(ordered, *is)
+		}
+	}
+	for _, is := range issues {
+		if !is.Fixable {
+			ordered = append(ordered, is)
+		}
+	}
+	return ordered
+}
+
+// parseCreatedTime 解析 created_time
+func parseCreatedTime(value string) (time.Time, bool) {
+	for _, layout := range createdTimeLayouts {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
+
+func joinIDs(group []*model.Wallpaper) string {
+	result := ""
+	for i, w := range group {
+		if i > 0 {
+			result += ","
+		}
+		result += strconv.Itoa(w.ID)
+	}
+	return result
+}
+
+func sortedKeys[K int | string, V any](m map[K]V) []K {
+	keys := make([]K, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i] < keys[j] })
+	return keys
+}
@@ -0,0 +1,162 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/gclm/galaxy-bing-wallpapers/pkg/database"
+	"github.com/gclm/galaxy-bing-wallpapers/pkg/model"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// defaultResolution 修复 URL 时补上的尺寸
+const defaultResolution = "_1920x1080.jpg"
+
+// fixResult 修复结果，按问题类型统计
+type fixResult map[string]int
+
+// applyFixes 修复可以安全自动处理的问题，其余问题只报告
+// objectIDs 为数据库中各记录的 _id，用于定位 id 重复的记录
+func applyFixes(ctx context.Context, issues []issue, objectIDs map[*model.Wallpaper]primitive.ObjectID) (fixResult, error) {
+	result := make(fixResult)
+
+	maxID, err := database.MaxWallpaperID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	nextID := maxID + 1
+
+	var missing []model.Wallpaper
+	for _, is := range issues {
+		if !is.Fixable {
+			continue
+		}
+		w := is.record
+
+		switch is.Kind {
+		case kindDuplicateID:
+			// id、市场和日期可能完全相同，按 _id 逐条更新，保证每条记录分到不同的 ID
+			objectID, ok := objectIDs[w]
+			if !ok {
+				return result, fmt.Errorf("%s %s: missing _id for id %d", w.Mkt, w.Datetime, w.ID)
+			}
+			n, err := database.UpdateWallpapers(ctx, bson.M{"_id": objectID}, bson.M{"$set": bson.M{"id": nextID}})
+			if err != nil {
+				return result, err
+			}
+			log.Printf("%s %s: reassigned id %d -> %d", w.Mkt, w.Datetime, w.ID, nextID)
+			nextID++
+			result[is.Kind] += int(n)
+
+		case kindMalformedURL:
+			fixed, _ := repairURL(w.Url)
+			filter := bson.M{"mkt": w.Mkt, "datetime": w.Datetime, "url": w.Url}
+			n, err := database.UpdateWallpapers(ctx, filter, bson.M{"$set": bson.M{"url": fixed}})
+			if err != nil {
+				return result, err
+			}
+			log.Printf("%s %s: url %s -> %s", w.Mkt, w.Datetime, w.Url, fixed)
+			result[is.Kind] += int(n)
+
+		case kindInvalidCreatedTime:
+			filter := bson.M{"mkt": w.Mkt, "datetime": w.Datetime, "created_time": w.CreatedTime}
+			n, err := database.UpdateWallpapers(ctx, filter, bson.M{"$set": bson.M{"created_time": w.Datetime}})
+			if err != nil {
+				return result, err
+			}
+			result[is.Kind] += int(n)
+
+		case kindStartDate:
+			objectID, ok := objectIDs[w]
+			if !ok {
+				return result, fmt.Errorf("%s %s: missing _id for id %d", w.Mkt, w.Datetime, w.ID)
+			}
+			set := bson.M{"datetime": is.target}
+			// created_time 是抓取日期，不能早于改正后的日期
+			if created, ok := parseCreatedTime(w.CreatedTime); ok && created.Format(dateLayout) < is.target {
+				set["created_time"] = is.target
+			}
+			n, err := database.UpdateWallpapers(ctx, bson.M{"_id": objectID}, bson.M{"$set": set})
+			if err != nil {
+				return result, err
+			}
+			log.Printf("%s %s: datetime -> %s", w.Mkt, w.Datetime, is.target)
+			result[is.Kind] += int(n)
+
+		case kindArchiveMissing:
+			record := *w
+			record.ID = nextID
+			nextID++
+			missing = append(missing, record)
+		}
+	}
+
+	if len(missing) > 0 {
+		bulk, err := database.BulkUpsertWallpapers(ctx, missing)
+		if err != nil {
+			return result, err
+		}
+		result[kindArchiveMissing] += bulk.Inserted
+		for i, reason := range bulk.Failed {
+			log.Printf("%s %s: failed to restore from archive: %s", missing[i].Mkt, missing[i].Datetime, reason)
+		}
+	}
+
+	return result, nil
+}
+
+// unresolved 修复后仍然存在的问题数：不可修复的问题，加上可修复但没有修复成功的问题
+func unresolved(issues []issue, fixed fixResult) int {
+	fixable := make(map[string]int)
+	n := 0
+	for _, is := range issues {
+		if is.Fixable {
+			fixable[is.Kind]++
+		} else {
+			n++
+		}
+	}
+	for kind, count := range fixable {
+		n += max(count-fixed[kind], 0)
+	}
+	return n
+}
+
+// repairURL 为缺少尺寸后缀的 OHR 图片地址补上 _1920x1080.jpg
+// 如 https://bing.com/th?id=OHR.IceHoleOtter_ZH-CN0106321041 或 ...0106321041.jpg
+func repairURL(url string) (string, bool) {
+	if !strings.Contains(url, "OHR.") {
+		return "", false
+	}
+
+	// 去掉 id 之后的其他查询参数
+	if i := strings.Index(url, "?id="); i >= 0 {
+		if j := strings.Index(url[i+1:], "&"); j >= 0 {
+			url = url[:i+1+j]
+		}
+	}
+	url = strings.TrimSuffix(url, ".jpg")
+
+	fixed := url + defaultResolution
+	if !(&model.Wallpaper{Url: fixed}).HasResolutionSuffix() {
+		return "", false
+	}
+	return fixed, true
+}
+
+// describeFixes 输出修复结果摘要
+func describeFixes(result fixResult) string {
+	if len(result) == 0 {
+		return "没有需要修复的问题"
+	}
+	var parts []string
+	for _, kind := range kinds {
+		if n, ok := result[kind]; ok {
+			parts = append(parts, fmt.Sprintf("%s: %d", kind, n))
+		}
+	}
+	return "已修复 " + strings.Join(parts, ", ")
+}
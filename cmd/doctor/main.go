@@ -0,0 +1,200 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/gclm/galaxy-bing-wallpapers/pkg/config"
+	"github.com/gclm/galaxy-bing-wallpapers/pkg/database"
+	"github.com/gclm/galaxy-bing-wallpapers/pkg/model"
+	"github.com/gclm/galaxy-bing-wallpapers/pkg/utils"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// report 检查结果
+type report struct {
+	Total   int            `json:"total"`   // 检查的记录数
+	Summary map[string]int `json:"summary"` // 按问题类型统计
+	Issues  []issue        `json:"issues"`
+	Fixed   fixResult      `json:"fixed,omitempty"`
+}
+
+func main() {
+	dataDir := flag.String("data", "data", "存档目录，与其中的 <mkt>_all.json 对比，为空时跳过存档检查")
+	markets := flag.String("market", "", "只检查指定市场，逗号分隔，默认检查全部")
+	bing := flag.Bool("bing", false, "与必应接口返回的最近约 15 天壁纸对比 datetime 和 startdate，需要访问网络")
+	fix := flag.Bool("fix", false, "修复可自动处理的问题：重复 ID、缺少尺寸的 URL、无效的 created_time、存档中缺失的记录、与 startdate 不一致的日期")
+	limit := flag.Int("limit", 20, "每类问题最多列出的条数，0 表示全部列出")
+	jsonOutput := flag.Bool("json", false, "以 JSON 输出完整结果")
+	flag.Parse()
+
+	// 加载配置
+	if _, err := config.LoadConfig(); err != nil {
+		log.Fatalf("Failed to load config: %v", err)
+	}
+
+	// 初始化数据库连接
+	if err := database.InitMongoDB(); err != nil {
+		log.Fatalf("Failed to connect to MongoDB: %v", err)
+	}
+
+	ctx := context.Background()
+
+	filter := bson.M{}
+	var selected []string
+	for _, mkt := range strings.Split(*markets, ",") {
+		if mkt = strings.TrimSpace(mkt); mkt != "" {
+			selected = append(selected, mkt)
+		}
+	}
+	if len(selected) > 0 {
+		filter["mkt"] = bson.M{"$in": selected}
+	} else {
+		selected = model.Markets
+	}
+
+	stored, err := database.ListStoredWallpapers(ctx, filter, bson.D{{Key: "mkt", Value: 1}, {Key: "datetime", Value: 1}})
+	if err != nil {
+		log.Fatalf("Failed to load wallpapers: %v", err)
+	}
+	// 检查只使用壁纸字段，修复时按记录找回 _id
+	wallpapers := make([]model.Wallpaper, len(stored))
+	objectIDs := make(map[*model.Wallpaper]primitive.ObjectID, len(stored))
+	for i := range stored {
+		wallpapers[i] = stored[i].Wallpaper
+		objectIDs[&wallpapers[i]] = stored[i].ObjectID
+	}
+
+	var issues []issue
+	issues = append(issues, checkRecords(wallpapers)...)
+	issues = append(issues, checkDuplicates(wallpapers)...)
+	issues = append(issues, checkGaps(wallpapers)...)
+
+	if *dataDir != "" {
+		archives, err := loadArchives(*dataDir, selected)
+		if err != nil {
+			log.Fatalf("Failed to load archives: %v", err)
+		}
+		issues = append(issues, checkArchive(wallpapers, archives)...)
+	}
+
+	if *bing {
+		recent := make(map[string][]utils.BingImage)
+		for _, mkt := range selected {
+			images, err := utils.FetchRecentImages(ctx, mkt)
+			if err != nil {
+				log.Fatalf("Failed to fetch recent Bing images for %s: %v", mkt, err)
+			}
+			recent[mkt] = images
+		}
+		issues = append(issues, checkStartDates(wallpapers, recent)...)
+	}
+
+	// 按问题类型排序，同类问题保持检查时的顺序
+	order := make(map[string]int)
+	for i, kind := range kinds {
+		order[kind] = i
+	}
+	sort.SliceStable(issues, func(i, j int) bool { return order[issues[i].Kind] < order[issues[j].Kind] })
+
+	r := &report{Total: len(wallpapers), Summary: make(map[string]int), Issues: issues}
+	for _, is := range issues {
+		r.Summary[is.Kind]++
+	}
+
+	var fixErr error
+	if *fix {
+		r.Fixed, fixErr = applyFixes(ctx, issues, objectIDs)
+		if fixErr != nil {
+			log.Printf("Failed to apply fixes: %v", fixErr)
+		}
+		log.Print(describeFixes(r.Fixed))
+	}
+
+	if *jsonOutput {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(r); err != nil {
+			log.Fatalf("Failed to encode report: %v", err)
+		}
+	} else {
+		printReport(os.Stdout, r, *limit)
+	}
+
+	// 存在未修复的问题或修复失败时返回非零退出码，便于在 CI 中使用
+	if fixErr != nil || unresolved(issues, r.Fixed) > 0 {
+		os.Exit(1)
+	}
+}
+
+// loadArchives 读取各市场的存档文件，文件不存在的市场跳过
+func loadArchives(dir string, markets []string) (map[string][]model.Wallpaper, error) {
+	archives := make(map[string][]model.Wallpaper)
+	for _, mkt := range markets {
+		data, err := os.ReadFile(filepath.Join(dir, mkt+"_all.json"))
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		var archive struct {
+			Data []model.Wallpaper `json:"data"`
+		}
+		if err := json.Unmarshal(data, &archive); err != nil {
+			return nil, fmt.Errorf("failed to parse %s_all.json: %v", mkt, err)
+		}
+		for i := range archive.Data {
+			archive.Data[i].Mkt = mkt
+		}
+		archives[mkt] = archive.Data
+	}
+	return archives, nil
+}
+
+// printReport 以表格输出问题汇总，并按类型列出问题明细
+func printReport(w io.Writer, r *report, limit int) {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	defer tw.Flush()
+
+	fmt.Fprintf(tw, "\n检查了 %d 条记录，发现 %d 个问题\n", r.Total, len(r.Issues))
+	fmt.Fprintln(tw, "类型\t数量\t已修复")
+	for _, kind := range kinds {
+		if n := r.Summary[kind]; n > 0 {
+			fmt.Fprintf(tw, "%s\t%d\t%d\n", kind, n, r.Fixed[kind])
+		}
+	}
+
+	listed := make(map[string]int)
+	for _, is := range r.Issues {
+		if limit > 0 && listed[is.Kind] >= limit {
+			continue
+		}
+		if listed[is.Kind] == 0 {
+			fmt.Fprintf(tw, "\n[%s]\n", is.Kind)
+		}
+		listed[is.Kind]++
+
+		id := ""
+		if is.ID != 0 {
+			id = fmt.Sprintf("#%d", is.ID)
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", is.Market, is.Datetime, id, is.Detail)
+	}
+	for _, kind := range kinds {
+		if more := r.Summary[kind] - listed[kind]; more > 0 {
+			fmt.Fprintf(tw, "[%s] 还有 %d 条未列出，使用 --limit 0 查看全部\n", kind, more)
+		}
+	}
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"strconv"
+
+	"github.com/gclm/galaxy-bing-wallpapers/pkg/model"
+)
+
+// archiveRecord data/<mkt>_all.json 中的单条记录，字段顺序与现有文件一致，不包含 mkt
+type archiveRecord struct {
+	ID            int    `json:"id"`
+	Title         string `json:"title"`
+	Url           string `json:"url"`
+	Datetime      string `json:"datetime"`
+	Copyright     string `json:"copyright"`
+	CopyrightLink string `json:"copyrightlink"`
+	Hsh           string `json:"hsh"`
+	CreatedTime   string `json:"created_time"`
+}
+
+// archive data/<mkt>_all.json 文件结构，与 model.WallpaperList 一致
+type archive struct {
+	Code  int             `json:"code"`
+	Msg   string          `json:"msg"`
+	Total int             `json:"total"`
+	Data  []archiveRecord `json:"data"`
+}
+
+var csvHeader = []string{"id", "title", "url", "datetime", "copyright", "copyrightlink", "hsh", "created_time"}
+
+func toRecords(wallpapers []model.Wallpaper) []archiveRecord {
+	records := make([]archiveRecord, len(wallpapers))
+	for i, w := range wallpapers {
+		records[i] = archiveRecord{
+			ID:            w.ID,
+			Title:         w.Title,
+			Url:           w.Url,
+			Datetime:      w.Datetime,
+			Copyright:     w.Copyright,
+			CopyrightLink: w.CopyrightLink,
+			Hsh:           w.Hsh,
+			CreatedTime:   w.CreatedTime,
+		}
+	}
+	return records
+}
+
+// encodeJSON 按 data/*_all.json 的格式编码：两个空格缩进、不转义 HTML 字符、末尾无换行
+func encodeJSON(records []archiveRecord) ([]byte, error) {
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+
+	err := encoder.Encode(archive{
+		Code:  200,
+		Msg:   "操作成功",
+		Total: len(records),
+		Data:  records,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return bytes.TrimSuffix(buf.Bytes(), []byte("\n")), nil
+}
+
+// encodeNDJSON 每行一条记录
+func encodeNDJSON(records []archiveRecord) ([]byte, error) {
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+
+	for _, record := range records {
+		if err := encoder.Encode(record); err != nil {
+			return nil, err
+		}
+	}
+	return buf.Bytes(), nil
+}
+
+// encodeCSV 带表头的 CSV
+func encodeCSV(records []archiveRecord) ([]byte, error) {
+	var buf bytes.Buffer
+	writer := csv.NewWriter(&buf)
+
+	if err := writer.Write(csvHeader); err != nil {
+		return nil, err
+	}
+	for _, r := range records {
+		row := []string{strconv.Itoa(r.ID), r.Title, r.Url, r.Datetime, r.Copyright, r.CopyrightLink, r.Hsh, r.CreatedTime}
+		if err := writer.Write(row); err != nil {
+			return nil, err
+		}
+	}
+
+	writer.Flush()
+	return buf.Bytes(), writer.Error()
+}
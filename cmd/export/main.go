@@ -0,0 +1,141 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/gclm/galaxy-bing-wallpapers/pkg/config"
+	"github.com/gclm/galaxy-bing-wallpapers/pkg/database"
+	"github.com/gclm/galaxy-bing-wallpapers/pkg/model"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// encoders 支持的导出格式及文件扩展名
+var encoders = map[string]struct {
+	ext    string
+	encode func([]archiveRecord) ([]byte, error)
+}{
+	"json":   {ext: ".json", encode: encodeJSON},
+	"ndjson": {ext: ".ndjson", encode: encodeNDJSON},
+	"csv":    {ext: ".csv", encode: encodeCSV},
+}
+
+// exportSort 导出顺序，id 重复时按日期、市场和 _id 排序，保证同样的数据总是生成同样的文件
+var exportSort = bson.D{
+	{Key: "id", Value: -1},
+	{Key: "datetime", Value: -1},
+	{Key: "mkt", Value: 1},
+	{Key: "_id", Value: 1},
+}
+
+func main() {
+	outDir := flag.String("out", "data", "输出目录")
+	formats := flag.String("format", "json", "导出格式，逗号分隔：json、ndjson、csv")
+	market := flag.String("market", "", "只导出指定市场，默认导出全部")
+	check := flag.Bool("check", false, "只检查文件是否需要更新，有变化时以非零状态退出")
+	flag.Parse()
+
+	// 加载配置
+	if _, err := config.LoadConfig(); err != nil {
+		log.Fatalf("Failed to load config: %v", err)
+	}
+
+	// 初始化数据库连接
+	if err := database.InitMongoDB(); err != nil {
+		log.Fatalf("Failed to connect to MongoDB: %v", err)
+	}
+
+	markets := model.Markets
+	if *market != "" {
+		if !model.IsValidMarket(*market) {
+			log.Fatalf("Unsupported market: %s", *market)
+		}
+		markets = []string{*market}
+	}
+
+	var formatList []string
+	for _, format := range strings.Split(*formats, ",") {
+		format = strings.TrimSpace(format)
+		if _, ok := encoders[format]; !ok {
+			log.Fatalf("Unsupported format: %s", format)
+		}
+		formatList = append(formatList, format)
+	}
+
+	if err := os.MkdirAll(*outDir, 0o755); err != nil {
+		log.Fatalf("Failed to create output directory: %v", err)
+	}
+
+	ctx := context.Background()
+	changed := 0
+	for _, mkt := range markets {
+		wallpapers, err := database.ListWallpapers(ctx, bson.M{"mkt": mkt}, exportSort)
+		if err != nil {
+			log.Fatalf("Failed to load %s wallpapers: %v", mkt, err)
+		}
+		records := toRecords(wallpapers)
+
+		for _, format := range formatList {
+			encoder := encoders[format]
+			content, err := encoder.encode(records)
+			if err != nil {
+				log.Fatalf("Failed to encode %s as %s: %v", mkt, format, err)
+			}
+
+			path := filepath.Join(*outDir, mkt+"_all"+encoder.ext)
+			updated, err := writeIfChanged(path, content, *check)
+			if err != nil {
+				log.Fatalf("Failed to write %s: %v", path, err)
+			}
+			if updated {
+				changed++
+				log.Printf("Updated %s (%d records)", path, len(records))
+			} else {
+				log.Printf("Unchanged %s (%d records)", path, len(records))
+			}
+		}
+	}
+
+	if *check && changed > 0 {
+		fmt.Fprintf(os.Stderr, "%d archive files are out of date\n", changed)
+		os.Exit(1)
+	}
+}
+
+// writeIfChanged 内容有变化时原子写入文件，返回是否有变化；dryRun 时只比较不写入
+func writeIfChanged(path string, content []byte, dryRun bool) (bool, error) {
+	existing, err := os.ReadFile(path)
+	if err == nil && bytes.Equal(existing, content) {
+		return false, nil
+	}
+	if err != nil && !os.IsNotExist(err) {
+		return false, err
+	}
+	if dryRun {
+		return true, nil
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return false, err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(content); err != nil {
+		tmp.Close()
+		return false, err
+	}
+	if err := tmp.Close(); err != nil {
+		return false, err
+	}
+	if err := os.Chmod(tmp.Name(), 0o644); err != nil {
+		return false, err
+	}
+	return true, os.Rename(tmp.Name(), path)
+}
@@ -5,6 +5,7 @@ import (
 
 	"github.com/gclm/galaxy-bing-wallpapers/pkg/config"
 	"github.com/gclm/galaxy-bing-wallpapers/pkg/database"
+	"github.com/gclm/galaxy-bing-wallpapers/pkg/model"
 	"github.com/gclm/galaxy-bing-wallpapers/pkg/utils"
 )
 
@@ -20,23 +21,11 @@ func main() {
 		log.Fatalf("Failed to connect to MongoDB: %v", err)
 	}
 
-	// 支持的市场代码
-	markets := []string{
-		"zh-CN", // 中国
-		"de-DE", // 德国
-		"en-CA", // 加拿大（英语）
-		"en-GB", // 英国
-		"en-IN", // 印度
-		"en-US", // 美国
-		"fr-FR", // 法国
-		"it-IT", // 意大利
-		"ja-JP", // 日本
-	}
-
 	// 获取每个市场的壁纸
-	for _, mkt := range markets {
+	for _, mkt := range model.Markets {
 		log.Printf("正在获取 %s 市场的壁纸...", mkt)
 		isNew, err := utils.FetchLatestWallpaper(mkt)
+		recordFetch(mkt, isNew, err)
 		if err != nil {
 			log.Printf("获取 %s 市场壁纸失败: %v", mkt, err)
 			continue
@@ -49,3 +38,19 @@ func main() {
 		}
 	}
 }
+
+// recordFetch 记录抓取结果，供就绪检查使用
+func recordFetch(mkt string, isNew bool, fetchErr error) {
+	fetchLog := model.FetchLog{
+		Mkt:     mkt,
+		Success: fetchErr == nil,
+		IsNew:   isNew,
+	}
+	if fetchErr != nil {
+		fetchLog.Error = fetchErr.Error()
+	}
+
+	if err := database.RecordFetch(fetchLog); err != nil {
+		log.Printf("记录 %s 市场抓取结果失败: %v", mkt, err)
+	}
+}
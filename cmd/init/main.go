@@ -1,87 +1,260 @@
 package main
 
 import (
-	"encoding/json"
+	"context"
+	"flag"
 	"fmt"
-	"io"
 	"log"
 	"os"
 	"path/filepath"
 	"strings"
 
+	"github.com/gclm/galaxy-bing-wallpapers/pkg/config"
 	"github.com/gclm/galaxy-bing-wallpapers/pkg/database"
+	"github.com/gclm/galaxy-bing-wallpapers/pkg/migrate"
 	"github.com/gclm/galaxy-bing-wallpapers/pkg/model"
 )
 
+// importer 数据导入器
+type importer struct {
+	batchSize   int
+	preserveIDs bool
+	dryRun      bool
+	nextID      int // 不保留源 ID 时分配的下一个 ID
+}
+
+// pendingRecord 等待写入的记录
+type pendingRecord struct {
+	index     int
+	wallpaper model.Wallpaper
+}
+
 func main() {
+	dataDir := flag.String("data", "data", "数据目录，读取其中的 <mkt>_all.json")
+	markets := flag.String("market", "", "只导入指定市场，逗号分隔，默认导入全部")
+	batchSize := flag.Int("batch-size", 500, "每批写入的记录数")
+	preserveIDs := flag.Bool("preserve-ids", false, "保留文件中的 id，默认按数据库当前最大 id 顺延分配；各市场文件的 id 范围相同，只能配合 --market 导入一个市场，id 冲突时导入失败")
+	dryRun := flag.Bool("dry-run", false, "只统计将要导入的数据，不写入数据库也不执行迁移")
+	verbose := flag.Bool("verbose", false, "输出每条被跳过或无效的记录")
+	reportPath := flag.String("report", "", "将完整导入结果写入 JSON 文件")
+	flag.Parse()
+
+	if *batchSize < 1 {
+		log.Fatalf("--batch-size must be positive")
+	}
+
+	filter := make(map[string]bool)
+	for _, mkt := range strings.Split(*markets, ",") {
+		if mkt = strings.TrimSpace(mkt); mkt != "" {
+			filter[mkt] = true
+		}
+	}
+
+	// 读取data目录，选出要导入的市场文件
+	entries, err := os.ReadDir(*dataDir)
+	if err != nil {
+		log.Fatalf("Failed to read data directory: %v", err)
+	}
+	var files []string
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), "_all.json") {
+			continue
+		}
+		mkt := strings.TrimSuffix(entry.Name(), "_all.json")
+		if len(filter) > 0 && !filter[mkt] {
+			continue
+		}
+		files = append(files, entry.Name())
+	}
+
+	// 各市场文件的 id 都从 1 开始，保留 id 同时导入多个市场必然与 id 唯一索引冲突
+	if *preserveIDs && len(files) > 1 {
+		log.Fatalf("--preserve-ids imports one market at a time, got %d market files; select one with --market", len(files))
+	}
+
+	// 加载配置
+	if _, err := config.LoadConfig(); err != nil {
+		log.Fatalf("Failed to load config: %v", err)
+	}
+
 	// 初始化数据库连接
 	if err := database.InitMongoDB(); err != nil {
 		log.Fatalf("Failed to connect to MongoDB: %v", err)
 	}
 
-	// 创建数据库索引
-	if err := database.CreateIndexes(); err != nil {
-		log.Fatalf("Failed to create indexes: %v", err)
+	ctx := context.Background()
+
+	// 执行数据库迁移（包括创建索引）
+	if !*dryRun {
+		if _, err := migrate.Up(ctx, 0); err != nil {
+			log.Fatalf("Failed to run migrations: %v", err)
+		}
 	}
 
-	// 读取data目录
-	dataDir := "data"
-	files, err := os.ReadDir(dataDir)
+	maxID, err := database.MaxWallpaperID(ctx)
 	if err != nil {
-		log.Fatalf("Failed to read data directory: %v", err)
+		log.Fatalf("Failed to get max wallpaper id: %v", err)
+	}
+
+	imp := &importer{
+		batchSize:   *batchSize,
+		preserveIDs: *preserveIDs,
+		dryRun:      *dryRun,
+		nextID:      maxID + 1,
 	}
 
 	// 遍历所有JSON文件
-	for _, file := range files {
-		if !file.IsDir() && strings.HasSuffix(file.Name(), "_all.json") {
-			mkt := strings.TrimSuffix(file.Name(), "_all.json")
-			log.Printf("Processing %s market data...", mkt)
-
-			// 读取文件内容
-			filePath := filepath.Join(dataDir, file.Name())
-			if err := importDataFile(filePath, mkt); err != nil {
-				log.Printf("Failed to import data for %s: %v", mkt, err)
-				continue
-			}
+	var reports []*marketReport
+	for _, name := range files {
+		mkt := strings.TrimSuffix(name, "_all.json")
+		log.Printf("Processing %s market data...", mkt)
+		report := imp.importFile(ctx, filepath.Join(*dataDir, name), mkt)
+		reports = append(reports, report)
+
+		if report.Error != "" {
+			log.Printf("Failed to import data for %s: %s", mkt, report.Error)
+		}
+	}
+
+	if err := printReport(os.Stdout, reports, *dryRun, *verbose); err != nil {
+		log.Fatalf("Failed to print report: %v", err)
+	}
+	if *reportPath != "" {
+		if err := writeReportFile(*reportPath, reports); err != nil {
+			log.Fatalf("Failed to write report: %v", err)
+		}
+	}
 
-			log.Printf("Successfully imported data for %s market", mkt)
+	for _, report := range reports {
+		if report.Error != "" {
+			os.Exit(1)
 		}
 	}
 }
 
-func importDataFile(filePath, mkt string) error {
-	// 打开文件
+// importFile 流式读取单个市场的数据文件并分批写入
+func (imp *importer) importFile(ctx context.Context, filePath, mkt string) *marketReport {
+	report := newMarketReport(mkt, filePath)
+
+	if !model.IsValidMarket(mkt) {
+		report.Error = fmt.Sprintf("unsupported market %q", mkt)
+		return report
+	}
+
 	file, err := os.Open(filePath)
 	if err != nil {
-		return fmt.Errorf("failed to open file: %v", err)
+		report.Error = fmt.Sprintf("failed to open file: %v", err)
+		return report
 	}
 	defer file.Close()
 
-	// 读取文件内容
-	content, err := io.ReadAll(file)
+	seen := make(map[string]bool)
+	batch := make([]pendingRecord, 0, imp.batchSize)
+
+	err = streamWallpapers(file, func(index int, wallpaper model.Wallpaper, decodeErr error) error {
+		if decodeErr != nil {
+			report.invalid(index, "", decodeErr.Error())
+			return nil
+		}
+
+		// 确保设置了市场代码
+		wallpaper.Mkt = mkt
+		if !imp.preserveIDs {
+			wallpaper.ID = 0
+		}
+
+		if err := wallpaper.Validate(); err != nil {
+			report.invalid(index, wallpaper.Datetime, err.Error())
+			return nil
+		}
+		if imp.preserveIDs && wallpaper.ID <= 0 {
+			report.invalid(index, wallpaper.Datetime, "id is required when preserving ids")
+			return nil
+		}
+		if seen[wallpaper.Datetime] {
+			report.skip(index, wallpaper.Datetime, "duplicate datetime in file")
+			return nil
+		}
+		seen[wallpaper.Datetime] = true
+
+		batch = append(batch, pendingRecord{index: index, wallpaper: wallpaper})
+		if len(batch) >= imp.batchSize {
+			if err := imp.flush(ctx, mkt, batch, report); err != nil {
+				return err
+			}
+			batch = batch[:0]
+		}
+		return nil
+	})
+	if err == nil {
+		err = imp.flush(ctx, mkt, batch, report)
+	}
 	if err != nil {
-		return fmt.Errorf("failed to read file: %v", err)
+		report.Error = err.Error()
 	}
 
-	// 解析JSON数据
-	var response model.WallpaperList
-	if err := json.Unmarshal(content, &response); err != nil {
-		return fmt.Errorf("failed to parse JSON: %v", err)
+	return report
+}
+
+// flush 写入一批记录：先一次查询过滤已存在的日期，再为新记录分配 ID 并批量写入
+func (imp *importer) flush(ctx context.Context, mkt string, batch []pendingRecord, report *marketReport) error {
+	if len(batch) == 0 {
+		return nil
 	}
 
-	// 批量导入数据
-	for _, wallpaper := range response.Data {
-		// 确保设置了市场代码
-		wallpaper.Mkt = mkt
+	datetimes := make([]string, len(batch))
+	for i, record := range batch {
+		datetimes[i] = record.wallpaper.Datetime
+	}
+	existing, err := database.ExistingDatetimes(ctx, mkt, datetimes)
+	if err != nil {
+		return err
+	}
 
-		// 保存到数据库
-		if err := database.SaveWallpaper(wallpaper); err != nil {
-			log.Printf("Warning: failed to save wallpaper %s: %v", wallpaper.Title, err)
+	var pending []pendingRecord
+	for _, record := range batch {
+		if existing[record.wallpaper.Datetime] {
+			report.skip(record.index, record.wallpaper.Datetime, "already exists")
 			continue
 		}
+		if !imp.preserveIDs {
+			record.wallpaper.ID = imp.nextID
+			imp.nextID++
+		}
+		pending = append(pending, record)
+	}
+
+	if imp.dryRun {
+		report.Inserted += len(pending)
+		return nil
 	}
 
-	log.Printf("Successfully imported %d wallpapers for %s market", len(response.Data), mkt)
+	wallpapers := make([]model.Wallpaper, len(pending))
+	for i, record := range pending {
+		wallpapers[i] = record.wallpaper
+	}
+
+	result, err := database.BulkUpsertWallpapers(ctx, wallpapers)
+	if err != nil {
+		return err
+	}
+
+	report.Inserted += result.Inserted
+	for i, record := range pending {
+		if reason, failed := result.Failed[i]; failed {
+			report.skip(record.index, record.wallpaper.Datetime, reason)
+		}
+	}
+	// 查询之后被其他进程写入的记录
+	if result.Matched > 0 {
+		report.Skipped += result.Matched
+		report.Reasons["skipped: already exists"] += result.Matched
+	}
+	// 保留 id 时写入失败通常是 id 已被其他壁纸占用，继续导入只会得到缺失记录的数据
+	if imp.preserveIDs && len(result.Failed) > 0 {
+		return fmt.Errorf("failed to write %d records with preserved ids, the ids may already be used by other wallpapers", len(result.Failed))
+	}
 
+	log.Printf("%s: wrote batch of %d (inserted %d)", mkt, len(pending), result.Inserted)
 	return nil
 }
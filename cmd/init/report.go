@@ -0,0 +1,115 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"text/tabwriter"
+)
+
+// recordIssue 被跳过或无效的单条记录
+type recordIssue struct {
+	Index    int    `json:"index"`    // 在文件 data 数组中的下标
+	Datetime string `json:"datetime"` // 记录日期
+	Reason   string `json:"reason"`   // 原因
+}
+
+// marketReport 单个市场的导入结果
+type marketReport struct {
+	Market   string         `json:"market"`
+	File     string         `json:"file"`
+	Inserted int            `json:"inserted"`
+	Skipped  int            `json:"skipped"`
+	Invalid  int            `json:"invalid"`
+	Reasons  map[string]int `json:"reasons"`
+	Issues   []recordIssue  `json:"issues,omitempty"`
+	Error    string         `json:"error,omitempty"`
+}
+
+func newMarketReport(mkt, file string) *marketReport {
+	return &marketReport{Market: mkt, File: file, Reasons: make(map[string]int)}
+}
+
+func (r *marketReport) skip(index int, datetime, reason string) {
+	r.Skipped++
+	r.addIssue(index, datetime, "skipped: "+reason)
+}
+
+func (r *marketReport) invalid(index int, datetime, reason string) {
+	r.Invalid++
+	r.addIssue(index, datetime, "invalid: "+reason)
+}
+
+func (r *marketReport) addIssue(index int, datetime, reason string) {
+	r.Reasons[reason]++
+	r.Issues = append(r.Issues, recordIssue{Index: index, Datetime: datetime, Reason: reason})
+}
+
+// printReport 以表格输出导入结果汇总，verbose 时列出每条被跳过或无效的记录
+func printReport(w io.Writer, reports []*marketReport, dryRun, verbose bool) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+
+	title := "导入结果"
+	if dryRun {
+		title += "（dry-run，未写入数据库）"
+	}
+	fmt.Fprintf(tw, "\n%s\n", title)
+	fmt.Fprintln(tw, "市场\t插入\t跳过\t无效\t说明")
+
+	var inserted, skipped, invalid int
+	for _, r := range reports {
+		note := r.Error
+		if note == "" {
+			note = formatReasons(r.Reasons)
+		}
+		fmt.Fprintf(tw, "%s\t%d\t%d\t%d\t%s\n", r.Market, r.Inserted, r.Skipped, r.Invalid, note)
+		inserted += r.Inserted
+		skipped += r.Skipped
+		invalid += r.Invalid
+	}
+	fmt.Fprintf(tw, "合计\t%d\t%d\t%d\t\n", inserted, skipped, invalid)
+
+	if verbose {
+		for _, r := range reports {
+			for _, issue := range r.Issues {
+				fmt.Fprintf(tw, "%s\t#%d\t%s\t%s\t\n", r.Market, issue.Index, issue.Datetime, issue.Reason)
+			}
+		}
+	}
+
+	return tw.Flush()
+}
+
+// formatReasons 按数量从多到少输出原因
+func formatReasons(reasons map[string]int) string {
+	keys := make([]string, 0, len(reasons))
+	for reason := range reasons {
+		keys = append(keys, reason)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if reasons[keys[i]] != reasons[keys[j]] {
+			return reasons[keys[i]] > reasons[keys[j]]
+		}
+		return keys[i] < keys[j]
+	})
+
+	result := ""
+	for i, reason := range keys {
+		if i > 0 {
+			result += "; "
+		}
+		result += fmt.Sprintf("%s ×%d", reason, reasons[reason])
+	}
+	return result
+}
+
+// writeReportFile 将完整结果写入 JSON 文件
+func writeReportFile(path string, reports []*marketReport) error {
+	data, err := json.MarshalIndent(reports, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0o644)
+}
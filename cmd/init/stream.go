@@ -0,0 +1,71 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/gclm/galaxy-bing-wallpapers/pkg/model"
+)
+
+// streamWallpapers 流式解析 model.WallpaperList 格式的文件，逐条回调 data 中的记录
+// 不会一次性把整个文件读入内存；单条记录解析失败时回调 err，不中断后续记录
+func streamWallpapers(r io.Reader, fn func(index int, wallpaper model.Wallpaper, err error) error) error {
+	decoder := json.NewDecoder(r)
+
+	if err := expectDelim(decoder, '{'); err != nil {
+		return err
+	}
+
+	for decoder.More() {
+		token, err := decoder.Token()
+		if err != nil {
+			return fmt.Errorf("failed to read key: %v", err)
+		}
+		key, ok := token.(string)
+		if !ok {
+			return fmt.Errorf("unexpected token %v", token)
+		}
+
+		// 只关心 data 字段，code/msg/total 等其他字段跳过
+		if key != "data" {
+			var skip json.RawMessage
+			if err := decoder.Decode(&skip); err != nil {
+				return fmt.Errorf("failed to parse %q: %v", key, err)
+			}
+			continue
+		}
+
+		if err := expectDelim(decoder, '['); err != nil {
+			return err
+		}
+		for index := 0; decoder.More(); index++ {
+			var raw json.RawMessage
+			if err := decoder.Decode(&raw); err != nil {
+				return fmt.Errorf("failed to read record %d: %v", index, err)
+			}
+
+			var wallpaper model.Wallpaper
+			decodeErr := json.Unmarshal(raw, &wallpaper)
+			if err := fn(index, wallpaper, decodeErr); err != nil {
+				return err
+			}
+		}
+		if err := expectDelim(decoder, ']'); err != nil {
+			return err
+		}
+	}
+
+	return expectDelim(decoder, '}')
+}
+
+func expectDelim(decoder *json.Decoder, want json.Delim) error {
+	token, err := decoder.Token()
+	if err != nil {
+		return fmt.Errorf("failed to parse JSON: %v", err)
+	}
+	if delim, ok := token.(json.Delim); !ok || delim != want {
+		return fmt.Errorf("failed to parse JSON: expected %q, got %v", want, token)
+	}
+	return nil
+}
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"text/tabwriter"
+
+	"github.com/gclm/galaxy-bing-wallpapers/pkg/config"
+	"github.com/gclm/galaxy-bing-wallpapers/pkg/database"
+	"github.com/gclm/galaxy-bing-wallpapers/pkg/migrate"
+)
+
+const usage = `用法: migrate <command> [flags]
+
+命令:
+  up      执行未执行的迁移
+  down    回滚最近执行的迁移
+  status  查看迁移状态
+
+使用 "migrate <command> -h" 查看命令参数`
+
+func main() {
+	if len(os.Args) < 2 {
+		fmt.Fprintln(os.Stderr, usage)
+		os.Exit(2)
+	}
+
+	name, args := os.Args[1], os.Args[2:]
+	var run func(ctx context.Context) error
+
+	switch name {
+	case "up":
+		fs := flag.NewFlagSet("up", flag.ExitOnError)
+		to := fs.Int("to", 0, "执行到指定版本（包含），0 表示最新版本")
+		fs.Parse(args)
+		run = func(ctx context.Context) error {
+			done, err := migrate.Up(ctx, *to)
+			printApplied("已执行", done)
+			return err
+		}
+	case "down":
+		fs := flag.NewFlagSet("down", flag.ExitOnError)
+		steps := fs.Int("steps", 1, "回滚的迁移数量")
+		fs.Parse(args)
+		run = func(ctx context.Context) error {
+			if *steps < 1 {
+				return fmt.Errorf("--steps must be positive")
+			}
+			done, err := migrate.Down(ctx, *steps)
+			printApplied("已回滚", done)
+			return err
+		}
+	case "status":
+		fs := flag.NewFlagSet("status", flag.ExitOnError)
+		jsonOutput := fs.Bool("json", false, "以 JSON 输出")
+		fs.Parse(args)
+		run = func(ctx context.Context) error {
+			states, err := migrate.Status(ctx)
+			if err != nil {
+				return err
+			}
+			return printStatus(states, *jsonOutput)
+		}
+	case "-h", "--help", "help":
+		fmt.Println(usage)
+		return
+	default:
+		fmt.Fprintf(os.Stderr, "unknown command %q\n\n%s\n", name, usage)
+		os.Exit(2)
+	}
+
+	// 加载配置
+	if _, err := config.LoadConfig(); err != nil {
+		log.Fatalf("Failed to load config: %v", err)
+	}
+
+	// 初始化数据库连接
+	if err := database.InitMongoDB(); err != nil {
+		log.Fatalf("Failed to connect to MongoDB: %v", err)
+	}
+
+	if err := run(context.Background()); err != nil {
+		log.Fatalf("%v", err)
+	}
+}
+
+func printApplied(action string, done []migrate.Migration) {
+	if len(done) == 0 {
+		fmt.Println("没有需要处理的迁移")
+		return
+	}
+	for _, m := range done {
+		fmt.Printf("%s %d_%s\n", action, m.Version, m.Name)
+	}
+}
+
+// printStatus 以表格输出迁移状态
+func printStatus(states []migrate.State, jsonOutput bool) error {
+	if jsonOutput {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(states)
+	}
+
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "版本\t名称\t状态\t执行时间")
+	for _, s := range states {
+		status, appliedAt := "pending", ""
+		if s.Applied {
+			status = "applied"
+			appliedAt = s.AppliedAt.Local().Format("2006-01-02 15:04:05")
+		}
+		if s.Unknown {
+			status += " (unknown)"
+		}
+		fmt.Fprintf(tw, "%d\t%s\t%s\t%s\n", s.Version, s.Name, status, appliedAt)
+	}
+	return tw.Flush()
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"text/tabwriter"
+
+	"github.com/gclm/galaxy-bing-wallpapers/pkg/config"
+	"github.com/gclm/galaxy-bing-wallpapers/pkg/database"
+	"github.com/gclm/galaxy-bing-wallpapers/pkg/migrate"
+	"github.com/gclm/galaxy-bing-wallpapers/pkg/snapshot"
+)
+
+func main() {
+	dir := flag.String("dir", "backups", "快照根目录，未指定 --snapshot 时恢复其中最新的快照")
+	from := flag.String("snapshot", "", "要恢复的快照目录")
+	replace := flag.Bool("replace", false, "用快照替换集合：写入临时集合后重命名覆盖原集合；默认合并，已存在的记录跳过")
+	verifyOnly := flag.Bool("verify-only", false, "只校验快照，不连接数据库")
+	dryRun := flag.Bool("dry-run", false, "校验并统计，不写入数据库")
+	flag.Parse()
+
+	path := *from
+	if path == "" {
+		snapshots, err := snapshot.List(*dir)
+		if err != nil {
+			log.Fatalf("Failed to list snapshots: %v", err)
+		}
+		if len(snapshots) == 0 {
+			log.Fatalf("No snapshots found in %s", *dir)
+		}
+		path = snapshots[len(snapshots)-1].Path
+	}
+
+	m, err := snapshot.ReadManifest(path)
+	if err != nil {
+		log.Fatalf("Failed to open snapshot %s: %v", path, err)
+	}
+
+	// 写入数据库前校验所有文件，避免恢复损坏的快照
+	if err := snapshot.Verify(path, m); err != nil {
+		log.Fatalf("Snapshot %s failed verification: %v", path, err)
+	}
+	log.Printf("Verified snapshot %s created at %s (%d files, %d images)",
+		m.Name, m.CreatedAt.Format("2006-01-02 15:04:05 MST"), len(m.Files), len(m.Images))
+	if *verifyOnly {
+		return
+	}
+
+	// 加载配置
+	if _, err := config.LoadConfig(); err != nil {
+		log.Fatalf("Failed to load config: %v", err)
+	}
+
+	// 初始化数据库连接
+	if err := database.InitMongoDB(); err != nil {
+		log.Fatalf("Failed to connect to MongoDB: %v", err)
+	}
+
+	ctx := context.Background()
+	results, err := snapshot.Restore(ctx, path, m, snapshot.RestoreOptions{Replace: *replace, DryRun: *dryRun})
+	printResults(results, *dryRun)
+	if err != nil {
+		log.Fatalf("Failed to restore snapshot: %v", err)
+	}
+
+	// 快照可能早于当前代码，恢复后补上新增的迁移
+	if !*dryRun {
+		if _, err := migrate.Up(ctx, 0); err != nil {
+			log.Fatalf("Failed to run migrations: %v", err)
+		}
+	}
+}
+
+// printResults 以表格输出每个集合的恢复结果
+func printResults(results []snapshot.CollectionResult, dryRun bool) {
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	defer tw.Flush()
+
+	title := "恢复结果"
+	if dryRun {
+		title += "（dry-run，未写入数据库）"
+	}
+	fmt.Fprintf(tw, "\n%s\n", title)
+	fmt.Fprintln(tw, "集合\t文档\t插入\t跳过")
+	for _, r := range results {
+		fmt.Fprintf(tw, "%s\t%d\t%d\t%d\n", r.Collection, r.Documents, r.Inserted, r.Skipped)
+	}
+}
@@ -2,14 +2,14 @@ package main
 
 import (
 	"fmt"
+	"log"
 	"os"
 	"path/filepath"
 	"runtime"
 
 	"github.com/gclm/galaxy-bing-wallpapers/pkg/config"
 	"github.com/gclm/galaxy-bing-wallpapers/pkg/database"
-	"github.com/gclm/galaxy-bing-wallpapers/pkg/handler"
-	"github.com/gclm/galaxy-bing-wallpapers/pkg/middleware"
+	"github.com/gclm/galaxy-bing-wallpapers/pkg/router"
 	"github.com/gin-gonic/gin"
 )
 
@@ -40,13 +40,16 @@ func main() {
 		panic(err)
 	}
 
+	// 创建缩略图缓存目录，就绪检查只检查目录状态
+	if err := os.MkdirAll(cfg.ThumbnailDir, 0o755); err != nil {
+		log.Printf("Warning: failed to create thumbnail directory: %v", err)
+	}
+
 	// 初始化 Gin
 	app := gin.New()
-	app.Use(middleware.CorsMiddleware())
-	app.Use(middleware.Recovery())
 
 	// 注册路由
-	setupRoutes(app)
+	router.Setup(app)
 
 	// 启动服务
 	addr := fmt.Sprintf(":%s", port)
@@ -55,21 +58,6 @@ func main() {
 	}
 }
 
-// setupRoutes 设置路由
-func setupRoutes(r *gin.Engine) {
-	// 根路径信息
-	r.GET("/", handler.GetInfo)
-
-	v1 := r.Group("/api/v1")
-	{
-		v1.GET("/today", handler.GetTodayWallpaper)
-		v1.GET("/random", handler.GetRandomWallpaper)
-		v1.GET("/list", middleware.TokenAuth(), handler.GetWallpaperList)
-		v1.GET("/date/:date", middleware.TokenAuth(), handler.GetWallpaperByDate)
-		v1.GET("/health", handler.HealthCheck)
-	}
-}
-
 // projectRoot 获取项目根目录
 func projectRoot() string {
 	_, b, _, _ := runtime.Caller(0)
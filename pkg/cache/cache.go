@@ -0,0 +1,98 @@
+// Package cache 进程内带过期时间的缓存，用于聚合查询等开销较大的接口
+//
+// 每个条目保存值的指纹（JSON 序列化后的 SHA-256 前缀），可直接用作 ETag。
+// 缓存只在单个进程内有效，Vercel 等多实例部署时各实例分别缓存。
+package cache
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// maxEntries 超过该数量时写入前先清理过期条目
+const maxEntries = 1024
+
+// Entry 缓存条目
+type Entry struct {
+	Value       interface{} // 缓存的值
+	Fingerprint string      // 值的指纹，值相同则指纹相同
+	expires     time.Time
+}
+
+// Cache 带过期时间的缓存，ttl 为 0 时不缓存
+type Cache struct {
+	ttl     time.Duration
+	mu      sync.Mutex
+	entries map[string]Entry
+}
+
+// New 创建缓存
+func New(ttl time.Duration) *Cache {
+	return &Cache{ttl: ttl, entries: make(map[string]Entry)}
+}
+
+// Get 获取未过期的条目
+func (c *Cache) Get(key string) (Entry, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, ok := c.entries[key]
+	if !ok || time.Now().After(entry.expires) {
+		return Entry{}, false
+	}
+	return entry, true
+}
+
+// Set 计算值的指纹并写入缓存，返回写入的条目
+func (c *Cache) Set(key string, value interface{}) (Entry, error) {
+	fingerprint, err := Fingerprint(value)
+	if err != nil {
+		return Entry{}, err
+	}
+	entry := Entry{Value: value, Fingerprint: fingerprint, expires: time.Now().Add(c.ttl)}
+	if c.ttl <= 0 {
+		return entry, nil
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if len(c.entries) >= maxEntries {
+		c.prune()
+	}
+	c.entries[key] = entry
+	return entry, nil
+}
+
+// prune 删除过期条目，仍然超出上限时全部清空，调用方持有锁
+func (c *Cache) prune() {
+	now := time.Now()
+	for key, entry := range c.entries {
+		if now.After(entry.expires) {
+			delete(c.entries, key)
+		}
+	}
+	if len(c.entries) >= maxEntries {
+		c.entries = make(map[string]Entry)
+	}
+}
+
+// Fingerprint 值的指纹：JSON 序列化后 SHA-256 的前 16 个字节
+func Fingerprint(value interface{}) (string, error) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode cache value: %v", err)
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:16]), nil
+}
+
+// Key 由多个部分拼接缓存键
+func Key(parts ...string) string {
+	return strings.Join(parts, "\x00")
+}
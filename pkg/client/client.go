@@ -0,0 +1,342 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gclm/galaxy-bing-wallpapers/pkg/model"
+)
+
+const (
+	defaultTimeout   = 30 * time.Second
+	defaultRetries   = 2
+	defaultRetryWait = 500 * time.Millisecond
+	defaultPageSize  = 20
+)
+
+// Client 壁纸 API 客户端
+type Client struct {
+	BaseURL    string        // 服务地址，如 https://bing.example.com
+	Token      string        // API 访问令牌，list/date 接口需要
+	HTTPClient *http.Client  // 发送请求使用的 HTTP 客户端
+	Retries    int           // 网络错误、429 和 5xx 响应的重试次数
+	RetryWait  time.Duration // 首次重试前的等待时间，之后按指数增长
+}
+
+// ImageOptions 图片类接口（today/random/date）的查询参数
+type ImageOptions struct {
+	Market string // 地区代码，today 默认 zh-CN
+	Width  int    // 图片宽度，默认 1920
+	Height int    // 图片高度，默认 1080
+	// Timezone 计算“今日”使用的 IANA 时区，如 Asia/Shanghai，默认为市场所在时区，只对 today 有效
+	Timezone string
+}
+
+// RandomOptions 随机接口的过滤和选取参数
+type RandomOptions struct {
+	ImageOptions
+	From     string // 起始日期（包含），YYYY-MM-DD，可选
+	To       string // 结束日期（包含），YYYY-MM-DD，可选
+	Res      string // 只返回原图为该分辨率的壁纸，如 1920x1080、UHD，可选
+	Seed     *int64 // 随机种子，相同种子返回相同结果，可选
+	NoRepeat bool   // 不返回该客户端最近拿到过的壁纸
+	ClientID string // 不重复模式的客户端标识，为空时服务端使用客户端 IP
+	Dedupe   bool   // 排除感知哈希近似的图片
+}
+
+// ListOptions 列表接口的查询参数
+type ListOptions struct {
+	Market   string // 地区代码，可选
+	From     string // 起始日期（包含），YYYY-MM-DD，可选
+	To       string // 结束日期（包含），YYYY-MM-DD，可选
+	Page     int    // 页码，默认 1
+	PageSize int    // 每页数量，默认 20
+
+	Photographer string  // 摄影师，不区分大小写完整匹配，可选
+	Agency       string  // 图片库，可选
+	Country      string  // 国家代码或名称，可选
+	Color        string  // 颜色 #rrggbb，返回调色板中有相近颜色的壁纸，可选
+	Tolerance    float64 // 颜色容差，0 表示使用服务端默认值
+}
+
+// ListPage 列表接口的一页结果
+type ListPage struct {
+	Wallpapers []model.WallpaperItem // 缩略图地址已按 BaseURL 补全
+	Total      int64
+	Page       int
+	PageSize   int
+}
+
+// HasNext 是否还有下一页
+// 服务端会调整无效或过大的 pageSize，所以按本页实际返回的条数估算，不用请求的 PageSize：
+// 本页为空时结束；最后一页不满时最多多请求一次空页
+func (p *ListPage) HasNext() bool {
+	n := len(p.Wallpapers)
+	return n > 0 && int64(p.Page*n) < p.Total
+}
+
+// New 创建客户端
+func New(baseURL, token string) *Client {
+	return &Client{
+		BaseURL:    strings.TrimSuffix(baseURL, "/"),
+		Token:      token,
+		HTTPClient: &http.Client{Timeout: defaultTimeout},
+		Retries:    defaultRetries,
+		RetryWait:  defaultRetryWait,
+	}
+}
+
+// Today 获取今日壁纸
+func (c *Client) Today(ctx context.Context, opts ImageOptions) (*model.ImageResponse, error) {
+	return c.image(ctx, "/api/v1/today", opts)
+}
+
+// Random 获取随机壁纸
+func (c *Client) Random(ctx context.Context, opts ImageOptions) (*model.ImageResponse, error) {
+	return c.image(ctx, "/api/v1/random", opts)
+}
+
+// RandomN 按条件获取 count 张互不相同的随机壁纸
+func (c *Client) RandomN(ctx context.Context, opts RandomOptions, count int) ([]model.ImageResponse, error) {
+	query := imageQuery(opts.ImageOptions)
+	query.Set("count", strconv.Itoa(count))
+	if opts.From != "" {
+		query.Set("from", opts.From)
+	}
+	if opts.To != "" {
+		query.Set("to", opts.To)
+	}
+	if opts.Res != "" {
+		query.Set("res", opts.Res)
+	}
+	if opts.Seed != nil {
+		query.Set("seed", strconv.FormatInt(*opts.Seed, 10))
+	}
+	if opts.NoRepeat {
+		query.Set("norepeat", "true")
+	}
+	if opts.ClientID != "" {
+		query.Set("client", opts.ClientID)
+	}
+	if opts.Dedupe {
+		query.Set("dedupe", "true")
+	}
+
+	// count 为 1 时服务端返回单个图片信息
+	if count <= 1 {
+		var resp model.ImageResponse
+		if err := c.get(ctx, "/api/v1/random", query, &resp); err != nil {
+			return nil, err
+		}
+		return []model.ImageResponse{resp}, nil
+	}
+
+	var resp struct {
+		Data []model.ImageResponse `json:"data"`
+	}
+	if err := c.get(ctx, "/api/v1/random", query, &resp); err != nil {
+		return nil, err
+	}
+	return resp.Data, nil
+}
+
+// ByDate 获取指定日期的壁纸，date 格式为 YYYY-MM-DD
+func (c *Client) ByDate(ctx context.Context, date string, opts ImageOptions) (*model.ImageResponse, error) {
+	return c.image(ctx, "/api/v1/date/"+url.PathEscape(date), opts)
+}
+
+// List 获取一页壁纸列表
+func (c *Client) List(ctx context.Context, opts ListOptions) (*ListPage, error) {
+	if opts.Page < 1 {
+		opts.Page = 1
+	}
+	if opts.PageSize < 1 {
+		opts.PageSize = defaultPageSize
+	}
+
+	query := url.Values{}
+	query.Set("page", strconv.Itoa(opts.Page))
+	query.Set("pageSize", strconv.Itoa(opts.PageSize))
+	if opts.Market != "" {
+		query.Set("mkt", opts.Market)
+	}
+	if opts.From != "" {
+		query.Set("from", opts.From)
+	}
+	if opts.To != "" {
+		query.Set("to", opts.To)
+	}
+	if opts.Photographer != "" {
+		query.Set("photographer", opts.Photographer)
+	}
+	if opts.Agency != "" {
+		query.Set("agency", opts.Agency)
+	}
+	if opts.Country != "" {
+		query.Set("country", opts.Country)
+	}
+	if opts.Color != "" {
+		query.Set("color", opts.Color)
+	}
+	if opts.Tolerance > 0 {
+		query.Set("tolerance", strconv.FormatFloat(opts.Tolerance, 'f', -1, 64))
+	}
+
+	var resp struct {
+		Data  []model.WallpaperItem `json:"data"`
+		Total int64                 `json:"total"`
+	}
+	if err := c.get(ctx, "/api/v1/list", query, &resp); err != nil {
+		return nil, err
+	}
+	// 服务端返回相对地址，补全后可以直接请求或用在页面中
+	for _, item := range resp.Data {
+		for size, thumb := range item.Thumbnails {
+			if strings.HasPrefix(thumb, "/") {
+				item.Thumbnails[size] = c.BaseURL + thumb
+			}
+		}
+	}
+
+	return &ListPage{
+		Wallpapers: resp.Data,
+		Total:      resp.Total,
+		Page:       opts.Page,
+		PageSize:   opts.PageSize,
+	}, nil
+}
+
+// ListAll 返回按页遍历壁纸列表的迭代器，从 opts.Page 开始
+func (c *Client) ListAll(ctx context.Context, opts ListOptions) *Iterator {
+	if opts.Page < 1 {
+		opts.Page = 1
+	}
+	return &Iterator{client: c, ctx: ctx, opts: opts}
+}
+
+// Markets 获取支持的市场列表
+func (c *Client) Markets(ctx context.Context) ([]model.MarketInfo, error) {
+	var resp struct {
+		Data []model.MarketInfo `json:"data"`
+	}
+	if err := c.get(ctx, "/api/v1/markets", nil, &resp); err != nil {
+		return nil, err
+	}
+	return resp.Data, nil
+}
+
+// image 请求图片类接口并返回图片信息
+func (c *Client) image(ctx context.Context, path string, opts ImageOptions) (*model.ImageResponse, error) {
+	var resp model.ImageResponse
+	if err := c.get(ctx, path, imageQuery(opts), &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// imageQuery 图片类接口的公共查询参数
+func imageQuery(opts ImageOptions) url.Values {
+	query := url.Values{}
+	query.Set("type", "json")
+	if opts.Market != "" {
+		query.Set("mkt", opts.Market)
+	}
+	if opts.Width > 0 {
+		query.Set("w", strconv.Itoa(opts.Width))
+	}
+	if opts.Height > 0 {
+		query.Set("h", strconv.Itoa(opts.Height))
+	}
+	if opts.Timezone != "" {
+		query.Set("tz", opts.Timezone)
+	}
+	return query
+}
+
+// get 发送 GET 请求并解析 JSON 响应，失败时按配置重试
+func (c *Client) get(ctx context.Context, path string, query url.Values, out interface{}) error {
+	endpoint := c.BaseURL + path
+	if len(query) > 0 {
+		endpoint += "?" + query.Encode()
+	}
+
+	var lastErr error
+	for attempt := 0; attempt <= c.Retries; attempt++ {
+		if attempt > 0 {
+			wait := c.RetryWait << (attempt - 1)
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(wait):
+			}
+		}
+
+		retry, err := c.do(ctx, endpoint, out)
+		if err == nil {
+			return nil
+		}
+		lastErr = err
+		if !retry {
+			break
+		}
+	}
+	return lastErr
+}
+
+// do 发送一次请求，返回是否值得重试
+func (c *Client) do(ctx context.Context, endpoint string, out interface{}) (bool, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return false, fmt.Errorf("failed to create request: %v", err)
+	}
+	req.Header.Set("Accept", "application/json")
+	if c.Token != "" {
+		req.Header.Set("Authorization", c.Token)
+	}
+
+	resp, err := c.httpClient().Do(req)
+	if err != nil {
+		return ctx.Err() == nil, fmt.Errorf("failed to request %s: %w", endpoint, err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return true, fmt.Errorf("failed to read response body: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		retry := resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500
+		return retry, newAPIError(resp.StatusCode, body)
+	}
+
+	if err := json.Unmarshal(body, out); err != nil {
+		return false, fmt.Errorf("failed to parse response: %w", err)
+	}
+	return false, nil
+}
+
+func (c *Client) httpClient() *http.Client {
+	if c.HTTPClient != nil {
+		return c.HTTPClient
+	}
+	return http.DefaultClient
+}
+
+// ParseResolution 解析 1920x1080 格式的分辨率
+func ParseResolution(res string) (int, int, error) {
+	w, h, ok := strings.Cut(strings.ToLower(res), "x")
+	width, werr := strconv.Atoi(w)
+	height, herr := strconv.Atoi(h)
+	if !ok || werr != nil || herr != nil || width <= 0 || height <= 0 {
+		return 0, 0, fmt.Errorf("invalid resolution %q, expected WIDTHxHEIGHT", res)
+	}
+	return width, height, nil
+}
@@ -0,0 +1,274 @@
+package client_test
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gclm/galaxy-bing-wallpapers/pkg/client"
+	"github.com/gclm/galaxy-bing-wallpapers/pkg/config"
+	"github.com/gclm/galaxy-bing-wallpapers/pkg/model"
+	"github.com/gclm/galaxy-bing-wallpapers/pkg/router"
+	"github.com/gin-gonic/gin"
+)
+
+const testToken = "test-token"
+
+// newRouterServer 使用真实路由启动服务，只用于不访问数据库的请求
+func newRouterServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	config.GlobalConfig = &config.Config{APIToken: testToken}
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	router.Setup(r)
+	server := httptest.NewServer(r)
+	t.Cleanup(server.Close)
+	return server
+}
+
+// newClient 创建重试等待很短的客户端
+func newClient(baseURL, token string) *client.Client {
+	c := client.New(baseURL, token)
+	c.RetryWait = time.Millisecond
+	return c
+}
+
+func TestMarketsAgainstRouter(t *testing.T) {
+	server := newRouterServer(t)
+
+	markets, err := newClient(server.URL, "").Markets(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(markets) != len(model.Markets) {
+		t.Fatalf("got %d markets, want %d", len(markets), len(model.Markets))
+	}
+}
+
+func TestErrorDecodingAgainstRouter(t *testing.T) {
+	server := newRouterServer(t)
+	ctx := context.Background()
+
+	_, err := newClient(server.URL, "").List(ctx, client.ListOptions{})
+	if !client.IsUnauthorized(err) || client.ErrorCode(err) != client.CodeTokenRequired {
+		t.Errorf("list without token: got %v, want %s", err, client.CodeTokenRequired)
+	}
+
+	_, err = newClient(server.URL, "wrong").List(ctx, client.ListOptions{})
+	if !client.IsUnauthorized(err) || client.ErrorCode(err) != client.CodeTokenInvalid {
+		t.Errorf("list with wrong token: got %v, want %s", err, client.CodeTokenInvalid)
+	}
+
+	_, err = newClient(server.URL, testToken).ByDate(ctx, "2024-13-01", client.ImageOptions{})
+	var apiErr *client.APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusBadRequest || apiErr.Code != client.CodeInvalidDate || apiErr.Message == "" {
+		t.Errorf("invalid date: got %#v", err)
+	}
+}
+
+func TestErrorDecodingWithoutErrorBody(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "gateway timeout", http.StatusBadGateway)
+	}))
+	defer server.Close()
+
+	c := newClient(server.URL, "")
+	c.Retries = 0
+	_, err := c.Markets(context.Background())
+	var apiErr *client.APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusBadGateway || apiErr.Message != http.StatusText(http.StatusBadGateway) {
+		t.Fatalf("got %#v", err)
+	}
+}
+
+func TestRetries(t *testing.T) {
+	tests := []struct {
+		name     string
+		statuses []int // 依次返回的状态码，用完后返回 200
+		retries  int
+		wantErr  bool
+		wantHits int32
+	}{
+		{"recovers after 5xx", []int{http.StatusServiceUnavailable, http.StatusInternalServerError}, 2, false, 3},
+		{"retries 429", []int{http.StatusTooManyRequests}, 2, false, 2},
+		{"gives up after retries", []int{503, 503, 503}, 2, true, 3},
+		{"does not retry 4xx", []int{http.StatusBadRequest}, 2, true, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var hits int32
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				n := atomic.AddInt32(&hits, 1)
+				if int(n) <= len(tt.statuses) {
+					w.WriteHeader(tt.statuses[n-1])
+					w.Write([]byte(`{"code":` + strconv.Itoa(tt.statuses[n-1]) + `,"error":"SERVICE_UNAVAILABLE","message":"unavailable"}`))
+					return
+				}
+				json.NewEncoder(w).Encode(model.ApiResponse{Code: 200, Message: "success", Data: []model.MarketInfo{{Code: "zh-CN"}}})
+			}))
+			defer server.Close()
+
+			c := newClient(server.URL, "")
+			c.Retries = tt.retries
+			_, err := c.Markets(context.Background())
+			if (err != nil) != tt.wantErr {
+				t.Errorf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if hits != tt.wantHits {
+				t.Errorf("hits = %d, want %d", hits, tt.wantHits)
+			}
+		})
+	}
+}
+
+func TestRetryStopsOnContextCancel(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	c := client.New(server.URL, "")
+	c.RetryWait = time.Hour
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if _, err := c.Markets(ctx); err != context.DeadlineExceeded {
+		t.Fatalf("got %v, want %v", err, context.DeadlineExceeded)
+	}
+}
+
+// listServer 模拟列表接口：共 total 条，每页数量超过 maxPageSize 时按 maxPageSize，无效时按 20
+func listServer(t *testing.T, total, maxPageSize int, requests *int32) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(requests, 1)
+		page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+		pageSize, _ := strconv.Atoi(r.URL.Query().Get("pageSize"))
+		if pageSize < 1 {
+			pageSize = 20
+		}
+		pageSize = min(pageSize, maxPageSize)
+
+		data := []model.WallpaperItem{}
+		for id := (page-1)*pageSize + 1; id <= min(page*pageSize, total); id++ {
+			data = append(data, model.WallpaperItem{
+				Wallpaper:  model.Wallpaper{ID: id},
+				Thumbnails: map[string]string{"320x180": "/api/v1/wallpapers/" + strconv.Itoa(id) + "/thumb?size=320x180"},
+			})
+		}
+		json.NewEncoder(w).Encode(model.ApiResponse{Code: 200, Message: "success", Data: data, Total: int64(total)})
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestIterator(t *testing.T) {
+	tests := []struct {
+		name         string
+		total        int
+		maxPageSize  int
+		pageSize     int
+		wantRequests int32
+	}{
+		{"exact pages", 40, 100, 20, 2},
+		{"partial last page", 45, 100, 20, 4}, // 最后一页不满时多请求一次空页
+		{"server clamps page size", 45, 10, 20, 6},
+		{"empty list", 0, 100, 20, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var requests int32
+			server := listServer(t, tt.total, tt.maxPageSize, &requests)
+
+			it := newClient(server.URL, testToken).ListAll(context.Background(), client.ListOptions{PageSize: tt.pageSize})
+			var ids []int
+			for it.Next() {
+				w := it.Wallpaper()
+				ids = append(ids, w.ID)
+				want := server.URL + "/api/v1/wallpapers/" + strconv.Itoa(w.ID) + "/thumb?size=320x180"
+				if got := w.Thumbnails["320x180"]; got != want {
+					t.Fatalf("wallpaper %d thumbnail = %q, want %q", w.ID, got, want)
+				}
+			}
+			if err := it.Err(); err != nil {
+				t.Fatal(err)
+			}
+			if len(ids) != tt.total {
+				t.Fatalf("got %d wallpapers, want %d", len(ids), tt.total)
+			}
+			for i, id := range ids {
+				if id != i+1 {
+					t.Fatalf("wallpaper %d has id %d, want %d", i, id, i+1)
+				}
+			}
+			if it.Total() != int64(tt.total) {
+				t.Errorf("Total() = %d, want %d", it.Total(), tt.total)
+			}
+			if requests != tt.wantRequests {
+				t.Errorf("requests = %d, want %d", requests, tt.wantRequests)
+			}
+		})
+	}
+}
+
+func TestIteratorStopsOnError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+		w.Write([]byte(`{"code":403,"error":"AUTH_TOKEN_INVALID","message":"invalid token"}`))
+	}))
+	defer server.Close()
+
+	it := newClient(server.URL, "wrong").ListAll(context.Background(), client.ListOptions{})
+	if it.Next() {
+		t.Fatal("Next() = true, want false")
+	}
+	if !client.IsUnauthorized(it.Err()) {
+		t.Fatalf("Err() = %v, want unauthorized", it.Err())
+	}
+}
+
+func TestDownload(t *testing.T) {
+	content := []byte("jpeg bytes")
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/ok.jpg" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write(content)
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	c := client.New("", "")
+
+	path := filepath.Join(dir, "ok.jpg")
+	result, err := c.Download(context.Background(), server.URL+"/ok.jpg", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sum := sha256.Sum256(content)
+	if result.Size != int64(len(content)) || result.SHA256 != hex.EncodeToString(sum[:]) {
+		t.Errorf("got %+v", result)
+	}
+	if data, err := os.ReadFile(path); err != nil || string(data) != string(content) {
+		t.Errorf("file content = %q, %v", data, err)
+	}
+
+	missing := filepath.Join(dir, "missing.jpg")
+	if _, err := c.Download(context.Background(), server.URL+"/missing.jpg", missing); err == nil {
+		t.Fatal("expected error for 404")
+	}
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != 1 {
+		t.Errorf("failed download left files behind: %v", entries)
+	}
+}
@@ -0,0 +1,100 @@
+package client
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+)
+
+// DownloadResult 下载结果
+type DownloadResult struct {
+	Path   string // 保存路径
+	Size   int64  // 文件大小（字节）
+	SHA256 string // 文件内容的 SHA-256
+}
+
+// Download 下载图片到 path，先写入 path.part，完成后再重命名，中断时不会留下不完整的文件
+func (c *Client) Download(ctx context.Context, imageURL, path string) (*DownloadResult, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, imageURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %v", err)
+	}
+
+	resp, err := c.httpClient().Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download %s: %w", imageURL, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to download %s: unexpected status code %d", imageURL, resp.StatusCode)
+	}
+
+	tmpPath := path + ".part"
+	file, err := os.Create(tmpPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create file: %v", err)
+	}
+
+	hash := sha256.New()
+	size, err := io.Copy(io.MultiWriter(file, hash), resp.Body)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil && resp.ContentLength >= 0 && size != resp.ContentLength {
+		err = fmt.Errorf("incomplete download: got %d of %d bytes", size, resp.ContentLength)
+	}
+	if err != nil {
+		os.Remove(tmpPath)
+		return nil, fmt.Errorf("failed to write %s: %v", path, err)
+	}
+
+	if err := os.Rename(tmpPath, path); err != nil {
+		os.Remove(tmpPath)
+		return nil, fmt.Errorf("failed to rename %s: %v", tmpPath, err)
+	}
+
+	return &DownloadResult{
+		Path:   path,
+		Size:   size,
+		SHA256: hex.EncodeToString(hash.Sum(nil)),
+	}, nil
+}
+
+// RemoteSize 通过 HEAD 请求获取远程文件大小，服务端未返回 Content-Length 时返回 -1
+func (c *Client) RemoteSize(ctx context.Context, imageURL string) (int64, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, imageURL, nil)
+	if err != nil {
+		return -1, fmt.Errorf("failed to create request: %v", err)
+	}
+
+	resp, err := c.httpClient().Do(req)
+	if err != nil {
+		return -1, fmt.Errorf("failed to request %s: %w", imageURL, err)
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return -1, fmt.Errorf("failed to request %s: unexpected status code %d", imageURL, resp.StatusCode)
+	}
+	return resp.ContentLength, nil
+}
+
+// FileSHA256 计算本地文件的 SHA-256
+func FileSHA256(path string) (string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	hash := sha256.New()
+	if _, err := io.Copy(hash, file); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
@@ -0,0 +1,66 @@
+package client
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// 服务端返回的错误码，与 handler.ErrorCode 保持一致
+const (
+	CodeWallpaperNotFound = "WALLPAPER_NOT_FOUND"
+	CodeInvalidMarket     = "INVALID_MARKET"
+	CodeInvalidDate       = "INVALID_DATE"
+	CodeInvalidTimezone   = "INVALID_TIMEZONE"
+	CodeInvalidParameter  = "INVALID_PARAMETER"
+	CodeTokenRequired     = "AUTH_TOKEN_REQUIRED"
+	CodeTokenInvalid      = "AUTH_TOKEN_INVALID"
+)
+
+// APIError 服务端返回的错误响应
+type APIError struct {
+	StatusCode int    `json:"code"`             // HTTP 状态码
+	Code       string `json:"error"`            // 错误码，如 WALLPAPER_NOT_FOUND
+	Message    string `json:"message"`          // 错误信息
+	Detail     string `json:"detail,omitempty"` // 补充说明
+}
+
+func (e *APIError) Error() string {
+	if e.Detail != "" {
+		return fmt.Sprintf("api error %d %s: %s (%s)", e.StatusCode, e.Code, e.Message, e.Detail)
+	}
+	return fmt.Sprintf("api error %d %s: %s", e.StatusCode, e.Code, e.Message)
+}
+
+// newAPIError 解析错误响应，响应体不是统一错误结构时使用状态码描述
+func newAPIError(statusCode int, body []byte) *APIError {
+	apiErr := &APIError{}
+	if err := json.Unmarshal(body, apiErr); err != nil || apiErr.Code == "" {
+		apiErr.Message = http.StatusText(statusCode)
+	}
+	apiErr.StatusCode = statusCode
+	return apiErr
+}
+
+// ErrorCode 获取错误对应的服务端错误码，非 APIError 时返回空字符串
+func ErrorCode(err error) string {
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.Code
+	}
+	return ""
+}
+
+// IsNotFound 是否为未找到壁纸
+func IsNotFound(err error) bool {
+	var apiErr *APIError
+	return errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound
+}
+
+// IsUnauthorized 是否为缺少或无效的访问令牌
+func IsUnauthorized(err error) bool {
+	var apiErr *APIError
+	return errors.As(err, &apiErr) &&
+		(apiErr.StatusCode == http.StatusUnauthorized || apiErr.StatusCode == http.StatusForbidden)
+}
@@ -0,0 +1,72 @@
+package client
+
+import (
+	"context"
+
+	"github.com/gclm/galaxy-bing-wallpapers/pkg/model"
+)
+
+// Iterator 壁纸列表迭代器，按需逐页请求
+//
+//	it := c.ListAll(ctx, client.ListOptions{Market: "zh-CN"})
+//	for it.Next() {
+//		w := it.Wallpaper()
+//	}
+//	if err := it.Err(); err != nil {
+//		...
+//	}
+type Iterator struct {
+	client *Client
+	ctx    context.Context
+	opts   ListOptions
+
+	page  *ListPage
+	index int
+	done  bool
+	err   error
+}
+
+// Next 移动到下一条壁纸，没有更多数据或出错时返回 false
+func (it *Iterator) Next() bool {
+	if it.err != nil {
+		return false
+	}
+
+	if it.page != nil && it.index+1 < len(it.page.Wallpapers) {
+		it.index++
+		return true
+	}
+
+	if it.done {
+		return false
+	}
+
+	page, err := it.client.List(it.ctx, it.opts)
+	if err != nil {
+		it.err = err
+		return false
+	}
+
+	it.page, it.index = page, 0
+	it.opts.Page++
+	it.done = !page.HasNext()
+	return len(page.Wallpapers) > 0
+}
+
+// Wallpaper 当前壁纸，附带缩略图地址
+func (it *Iterator) Wallpaper() model.WallpaperItem {
+	return it.page.Wallpapers[it.index]
+}
+
+// Total 列表总数，第一次调用 Next 之后可用
+func (it *Iterator) Total() int64 {
+	if it.page == nil {
+		return 0
+	}
+	return it.page.Total
+}
+
+// Err 迭代过程中遇到的错误
+func (it *Iterator) Err() error {
+	return it.err
+}
@@ -4,7 +4,11 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
 	"sync"
+	"time"
 
 	"github.com/joho/godotenv"
 )
@@ -14,8 +18,36 @@ type Config struct {
 	MongoDBURI string
 	APIToken   string
 	GinMode    string
+
+	// ReadyFetchMaxAge 就绪检查中各市场最近一次成功抓取允许的最大间隔
+	ReadyFetchMaxAge time.Duration
+
+	// 今日壁纸不存在时的回退策略
+	TodayFallback        []string // 按顺序尝试：latest（该市场最近一张）、market（其他市场的今日壁纸），为空表示不回退
+	TodayFallbackMarkets []string // market 策略尝试的市场顺序，为空时按支持的市场列表顺序
+
+	// RandomNoRepeatWindow 随机接口不重复模式的时间窗口，最长 30 天（历史记录的保留时间）
+	RandomNoRepeatWindow time.Duration
+
+	// CacheTTL 日历、归档、统计等聚合接口结果的进程内缓存时间，为 0 时不缓存
+	CacheTTL time.Duration
+
+	// 缩略图
+	ThumbnailSizes []string // 允许的尺寸，WIDTHxHEIGHT，第一个为默认尺寸
+	ThumbnailDir   string   // 缩略图缓存目录
+	ImageMirrorDir string   // cmd/backup 的快照根目录，其中镜像的图片优先用作原图，为空时总是从必应下载
+
+	// CORS 跨域配置
+	CORSAllowedOrigins   []string      // 允许的来源，支持精确匹配、*.example.com 通配子域名和 regex: 前缀的正则
+	CORSAllowedHeaders   []string      // 允许的请求头
+	CORSExposedHeaders   []string      // 暴露给浏览器的响应头
+	CORSAllowCredentials bool          // 是否允许携带凭证
+	CORSMaxAge           time.Duration // 预检请求缓存时间
 }
 
+// thumbnailSizePattern 缩略图尺寸的格式
+var thumbnailSizePattern = regexp.MustCompile(`^[1-9][0-9]*x[1-9][0-9]*$`)
+
 // GlobalConfig 全局配置实例
 var (
 	GlobalConfig *Config
@@ -39,6 +71,25 @@ func LoadConfig() (*Config, error) {
 			MongoDBURI: getRequiredEnv("MONGODB_URI"),
 			APIToken:   getEnvWithDefault("API_TOKEN", "FuO2wOA4d6KUYvry"),
 			GinMode:    getEnvWithDefault("GIN_MODE", "release"),
+
+			ReadyFetchMaxAge: getDurationWithDefault("READY_FETCH_MAX_AGE", 36*time.Hour),
+
+			TodayFallback:        getListWithDefault("TODAY_FALLBACK", "latest,market"),
+			TodayFallbackMarkets: getListWithDefault("TODAY_FALLBACK_MARKETS", ""),
+
+			RandomNoRepeatWindow: getDurationWithDefault("RANDOM_NO_REPEAT_WINDOW", 7*24*time.Hour),
+
+			CacheTTL: getDurationWithDefault("CACHE_TTL", 10*time.Minute),
+
+			ThumbnailSizes: getListWithDefault("THUMBNAIL_SIZES", "320x180,640x360,1280x720"),
+			ThumbnailDir:   getEnvWithDefault("THUMBNAIL_DIR", filepath.Join(os.TempDir(), "galaxy-bing-thumbnails")),
+			ImageMirrorDir: getEnvWithDefault("IMAGE_MIRROR_DIR", ""),
+
+			CORSAllowedOrigins:   getListWithDefault("CORS_ALLOWED_ORIGINS", "*"),
+			CORSAllowedHeaders:   getListWithDefault("CORS_ALLOWED_HEADERS", "Content-Type,Authorization,X-Timezone,X-Client-ID"),
+			CORSExposedHeaders:   getListWithDefault("CORS_EXPOSED_HEADERS", "ETag,X-RateLimit-Limit,X-RateLimit-Remaining,X-RateLimit-Reset,X-Wallpaper-Fallback"),
+			CORSAllowCredentials: getBoolWithDefault("CORS_ALLOW_CREDENTIALS", false),
+			CORSMaxAge:           getDurationWithDefault("CORS_MAX_AGE", 10*time.Minute),
 		}
 
 		// 验证必需的配置
@@ -46,6 +97,31 @@ func LoadConfig() (*Config, error) {
 			err = fmt.Errorf("MONGODB_URI is required but not set")
 			return
 		}
+		for _, strategy := range GlobalConfig.TodayFallback {
+			if strategy != "latest" && strategy != "market" && strategy != "none" {
+				err = fmt.Errorf("invalid TODAY_FALLBACK strategy %q, expected latest, market or none", strategy)
+				return
+			}
+		}
+		// 允许任意来源携带凭证等于让任何网站以用户身份发起请求
+		if GlobalConfig.CORSAllowCredentials {
+			for _, origin := range GlobalConfig.CORSAllowedOrigins {
+				if origin == "*" {
+					err = fmt.Errorf("CORS_ALLOW_CREDENTIALS=true cannot be combined with CORS_ALLOWED_ORIGINS=*, list the allowed origins explicitly")
+					return
+				}
+			}
+		}
+		if len(GlobalConfig.ThumbnailSizes) == 0 {
+			err = fmt.Errorf("THUMBNAIL_SIZES must contain at least one size")
+			return
+		}
+		for _, size := range GlobalConfig.ThumbnailSizes {
+			if !thumbnailSizePattern.MatchString(size) {
+				err = fmt.Errorf("invalid THUMBNAIL_SIZES entry %q, expected WIDTHxHEIGHT such as 320x180", size)
+				return
+			}
+		}
 	})
 
 	if err != nil {
@@ -110,3 +186,34 @@ func getEnvWithDefault(key, defaultValue string) string {
 	}
 	return defaultValue
 }
+
+// getDurationWithDefault 获取时长类型的环境变量（如 36h、90m），无效或不存在时返回默认值
+func getDurationWithDefault(key string, defaultValue time.Duration) time.Duration {
+	if value := os.Getenv(key); value != "" {
+		if d, err := time.ParseDuration(value); err == nil {
+			return d
+		}
+	}
+	return defaultValue
+}
+
+// getListWithDefault 获取逗号分隔的列表类型环境变量，忽略空项
+func getListWithDefault(key, defaultValue string) []string {
+	var list []string
+	for _, item := range strings.Split(getEnvWithDefault(key, defaultValue), ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+	return list
+}
+
+// getBoolWithDefault 获取布尔类型的环境变量，无效或不存在时返回默认值
+func getBoolWithDefault(key string, defaultValue bool) bool {
+	if value := os.Getenv(key); value != "" {
+		if b, err := strconv.ParseBool(value); err == nil {
+			return b
+		}
+	}
+	return defaultValue
+}
@@ -0,0 +1,51 @@
+package copyright
+
+import "strings"
+
+// agencies 已知图片库，按顺序匹配小写名称中的关键字
+// 更具体的写在前面，如 "Offset by Shutterstock" 应归为 Offset
+var agencies = []struct {
+	keyword string
+	name    string
+}{
+	{"offset", "Offset"},
+	{"getty", "Getty Images"},
+	{"shutterstock", "Shutterstock"},
+	{"alamy", "Alamy"},
+	{"minden", "Minden Pictures"},
+	{"tandem", "Tandem Stills + Motion"},
+	{"estock", "eStock Photo"},
+	{"amazing aerial", "Amazing Aerial Agency"},
+	{"plainpicture", "plainpicture"},
+	{"danita", "Danita Delimont"},
+	{"adobe", "Adobe Stock"},
+	{"500px", "500px"},
+	{"aurora", "Aurora Photos"},
+	{"cavan", "Cavan Images"},
+	{"age fotostock", "age fotostock"},
+	{"agefotostock", "age fotostock"},
+	{"gallery stock", "Gallery Stock"},
+	{"nimia", "Nimia"},
+	{"masterfile", "Masterfile"},
+	{"science photo library", "Science Photo Library"},
+	{"nature picture library", "Nature Picture Library"},
+	{"naturepl", "Nature Picture Library"},
+	{"superstock", "SuperStock"},
+	{"corbis", "Corbis"},
+	{"reuters", "Reuters"},
+	{"nasa", "NASA"},
+	{"bing image creator", "Bing Image Creator"},
+}
+
+// CanonicalAgency 将图片库名称统一为规范写法，如 "Getty Images Plus"、"AFP via Getty Images" 都返回 "Getty Images"
+// 不是已知图片库时原样返回并且 ok 为 false
+func CanonicalAgency(name string) (string, bool) {
+	name = strings.Join(strings.Fields(name), " ")
+	lower := strings.ToLower(name)
+	for _, agency := range agencies {
+		if strings.Contains(lower, agency.keyword) {
+			return agency.name, true
+		}
+	}
+	return name, false
+}
@@ -0,0 +1,208 @@
+// Package copyright 将必应壁纸的版权信息解析为结构化字段
+//
+// 版权信息的格式为“描述 (© 摄影师/图片库)”，描述由逗号分隔的主题、地点和国家组成，
+// 各市场使用本地语言，如：
+//
+//	Great Blue Hole, Belize (© JamiesOnAMission/Shutterstock)
+//	欧亚水獭，莱利斯塔德，荷兰 (© Ernst Dirksen/Minden Pictures)
+//	狩りの練習をする子ギツネ, カナダ ケベック州 (© Vlad Kamenski/Shutterstock)
+package copyright
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/gclm/galaxy-bing-wallpapers/pkg/model"
+)
+
+var (
+	// creditPattern 末尾的署名部分，兼容全角括号和缺少括号的写法
+	creditPattern = regexp.MustCompile(`^(.*?)\s*(?:[(（]\s*(?:Photo\s*)?(?:©|Ⓒ|\([cC]\))?\s*([^()（）]*?)\s*[)）]|(?:©|Ⓒ)\s*(.+))$`)
+	// tagPattern 开头的【今日七夕】之类的标签
+	tagPattern = regexp.MustCompile(`^【[^】]*】\s*`)
+	// separatorPattern 描述中各部分的分隔符
+	separatorPattern = regexp.MustCompile(`\s*[,，、]\s*`)
+)
+
+// maxCountryRunes 中日文按前缀匹配国家名称时的最大长度
+const maxCountryRunes = 8
+
+// spaceReplacer 统一各种不可见和特殊空白字符
+var spaceReplacer = strings.NewReplacer(
+	"\u200b", "", // 零宽空格
+	"\ufeff", "", // BOM
+	"\u00a0", " ", // 不换行空格
+	"\u202f", " ", // 窄不换行空格
+	"\u3000", " ", // 全角空格
+)
+
+// Parse 解析版权信息，mkt 为壁纸所属市场，用于选择语言相关的规则；无法识别的部分留空
+func Parse(copyright, mkt string) model.Credit {
+	text := strings.TrimSpace(spaceReplacer.Replace(copyright))
+	text = tagPattern.ReplaceAllString(text, "")
+
+	var credit model.Credit
+	description := text
+	if m := creditPattern.FindStringSubmatch(text); m != nil {
+		description = m[1]
+		byline := m[2]
+		if byline == "" {
+			byline = m[3]
+		}
+		credit.Photographer, credit.Agency = parseByline(byline)
+	}
+
+	parseDescription(description, mkt, &credit)
+	return credit
+}
+
+// parseByline 解析署名，如 "Ernst Dirksen/Minden Pictures"
+// 最后一段为图片库，第一段为摄影师；只有一段时按是否为已知图片库区分
+func parseByline(byline string) (string, string) {
+	var parts []string
+	for _, part := range strings.Split(byline, "/") {
+		if part = strings.TrimSpace(part); part != "" {
+			parts = append(parts, part)
+		}
+	}
+
+	switch len(parts) {
+	case 0:
+		return "", ""
+	case 1:
+		if agency, ok := CanonicalAgency(parts[0]); ok {
+			return "", agency
+		}
+		return parts[0], ""
+	}
+
+	agency, _ := CanonicalAgency(parts[len(parts)-1])
+	return parts[0], agency
+}
+
+// parseDescription 将描述拆分为主题、地点和国家
+func parseDescription(description, mkt string, credit *model.Credit) {
+	var segments []string
+	for _, segment := range separatorPattern.Split(strings.TrimSpace(description), -1) {
+		if segment != "" {
+			segments = append(segments, segment)
+		}
+	}
+	if len(segments) == 0 {
+		return
+	}
+	lang, home := marketLanguage(mkt), marketCountry(mkt)
+
+	// 从后往前在主题之后的段落中找国家，中文有时把国家写在最前面，最后再检查第一段
+	order := make([]int, 0, len(segments))
+	for i := len(segments) - 1; i >= 1; i-- {
+		order = append(order, i)
+	}
+	if len(segments) > 1 {
+		order = append(order, 0)
+	}
+
+	removed := -1
+	for _, i := range order {
+		// 第一段是主题，只有整段是国家时才识别，否则 "Turkey tail mushroom" 会被识别为土耳其；
+		// 早期中文描述以“美国犹他州”之类的地点开头，国家后面紧跟一级行政区时例外
+		name, rest, code, ok := findCountry(segments[i], lang, home, i > 0)
+		if !ok && i == 0 {
+			name, rest, code, ok = findCountry(segments[i], lang, home, true)
+			ok = ok && isRegionName(rest)
+		}
+		if ok {
+			credit.Country, credit.CountryCode = name, code
+			if rest == "" {
+				removed = i
+			} else {
+				segments[i] = rest
+			}
+			break
+		}
+		// 最后一段是州、省等一级行政区时没有写国家，不再查找前面的段落，
+		// 否则 "Long Island, New York" 中的 Island 会被当作德语的冰岛
+		if i == len(segments)-1 {
+			if _, isRegion := lookupRegion(segments[i]); isRegion {
+				break
+			}
+		}
+	}
+
+	var rest []string
+	for i, segment := range segments {
+		if i != removed {
+			rest = append(rest, segment)
+		}
+	}
+	if len(rest) == 0 {
+		return
+	}
+	credit.Subject = rest[0]
+	credit.Location = strings.Join(rest[1:], ", ")
+
+	// 没有写国家时，按州、省等一级行政区推断国家代码
+	if credit.CountryCode == "" {
+		for i := len(rest) - 1; i >= 1; i-- {
+			if code, ok := inferCountry(rest[i], mkt); ok {
+				credit.CountryCode = code
+				break
+			}
+		}
+	}
+}
+
+// findCountry 识别段落中的国家，返回国家名称和剩余部分（整段都是国家时为空）
+// 只识别市场语言和英文的国家名称；partial 时还支持以空格分隔的首尾词（如“中国 山西省”、“Oregon USA”），
+// 中日文还支持不带空格的前缀（如“中国山东省”）
+func findCountry(segment, lang, home string, partial bool) (string, string, string, bool) {
+	region, isRegion := lookupRegion(segment)
+	if code, ok := lookupLocalCountry(segment, lang); ok {
+		// 既是国家又是州时（如 Georgia），本市场所在国家的州优先，由 inferCountry 推断国家
+		if isRegion && region == home {
+			return "", "", "", false
+		}
+		return segment, "", code, true
+	}
+
+	// 州名可能以国家名称结尾，如 New Mexico
+	if isRegion || !partial {
+		return "", "", "", false
+	}
+
+	words := strings.Fields(segment)
+	if len(words) > 1 {
+		if code, ok := lookupLocalCountry(words[0], lang); ok {
+			return words[0], strings.Join(words[1:], " "), code, true
+		}
+		if code, ok := lookupLocalCountry(words[len(words)-1], lang); ok {
+			return words[len(words)-1], strings.Join(words[:len(words)-1], " "), code, true
+		}
+	}
+
+	if lang == "zh" || lang == "ja" {
+		runes := []rune(segment)
+		for n := min(len(runes)-1, maxCountryRunes); n >= 2; n-- {
+			if code, ok := lookupLocalCountry(string(runes[:n]), lang); ok {
+				return string(runes[:n]), string(runes[n:]), code, true
+			}
+		}
+	}
+	return "", "", "", false
+}
+
+// inferCountry 按一级行政区推断国家代码，日文段落可能以空格分隔多个行政区，如“埼玉県 秩父市”
+func inferCountry(segment, mkt string) (string, bool) {
+	if code, ok := lookupRegion(segment); ok {
+		return code, true
+	}
+	for _, word := range strings.Fields(segment) {
+		if code, ok := lookupRegion(word); ok {
+			return code, true
+		}
+		if mkt == "ja-JP" && isJapanesePrefecture(word) {
+			return "JP", true
+		}
+	}
+	return "", false
+}
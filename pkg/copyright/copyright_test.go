@@ -0,0 +1,150 @@
+package copyright
+
+import (
+	"testing"
+
+	"github.com/gclm/galaxy-bing-wallpapers/pkg/model"
+)
+
+// 用例均取自 data/ 下各市场归档中的版权信息
+func TestParseCountry(t *testing.T) {
+	tests := []struct {
+		name      string
+		mkt       string
+		copyright string
+		want      model.Credit // 只比较主题、地点和国家
+	}{
+		{
+			name:      "german Island as whole segment",
+			mkt:       "de-DE",
+			copyright: "Wasserfall in Skaftafell, Vatnajökull-Nationalpark, Island (© Nopasorn Kowathanakul/Getty Images)",
+			want:      model.Credit{Subject: "Wasserfall in Skaftafell", Location: "Vatnajökull-Nationalpark", Country: "Island", CountryCode: "IS"},
+		},
+		{
+			name:      "german Island inside Long Island",
+			mkt:       "de-DE",
+			copyright: "Flussseeschwalbenvater mit Küken, Nickerson Beach, Long Island, New York (© Vicki Jauron, Babylon and Beyond Photography/Getty Images)",
+			want:      model.Credit{Subject: "Flussseeschwalbenvater mit Küken", Location: "Nickerson Beach, Long Island, New York", CountryCode: "US"},
+		},
+		{
+			name:      "english Island is not a country",
+			mkt:       "en-US",
+			copyright: "Kodiak National Wildlife Refuge, Kodiak Island, Alaska (© Ian Shive/Tandem Stills + Motion)",
+			want:      model.Credit{Subject: "Kodiak National Wildlife Refuge", Location: "Kodiak Island, Alaska", CountryCode: "US"},
+		},
+		{
+			name:      "island before canadian province",
+			mkt:       "en-CA",
+			copyright: "Broken Group Islands, Pacific Rim National Park Reserve, Vancouver Island, British Columbia (© Ron Watts/Design Pics/Getty Images)",
+			want:      model.Credit{Subject: "Broken Group Islands", Location: "Pacific Rim National Park Reserve, Vancouver Island, British Columbia", CountryCode: "CA"},
+		},
+		{
+			name:      "country word in subject",
+			mkt:       "en-US",
+			copyright: "Turkey tail mushroom, Brevard, North Carolina (© Bill Gozansky/Alamy)",
+			want:      model.Credit{Subject: "Turkey tail mushroom", Location: "Brevard, North Carolina", CountryCode: "US"},
+		},
+		{
+			name:      "country as last segment",
+			mkt:       "en-US",
+			copyright: "Aerial view of colorful boats in the Mediterranean Sea in Ölüdeniz, Turkey (© den-belitsky/Getty Images)",
+			want:      model.Credit{Subject: "Aerial view of colorful boats in the Mediterranean Sea in Ölüdeniz", Country: "Turkey", CountryCode: "TR"},
+		},
+		{
+			name:      "country word in location",
+			mkt:       "it-IT",
+			copyright: "Festa di San Gennaro a Mulberry Street, Little Italy, New York (© Philip Scalia/Alamy Stock Photo)",
+			want:      model.Credit{Subject: "Festa di San Gennaro a Mulberry Street", Location: "Little Italy, New York", CountryCode: "US"},
+		},
+		{
+			name:      "country word in region name",
+			mkt:       "de-DE",
+			copyright: "Winterstein, Nationalpark Sächsische Schweiz, Sachsen (© Frank Bienewald/Getty Images)",
+			want:      model.Credit{Subject: "Winterstein", Location: "Nationalpark Sächsische Schweiz, Sachsen", CountryCode: "DE"},
+		},
+		{
+			name:      "georgia as state in us market",
+			mkt:       "en-US",
+			copyright: "Bonaventure Cemetery, Savannah, Georgia (© Kelly vanDellen/Alamy)",
+			want:      model.Credit{Subject: "Bonaventure Cemetery", Location: "Savannah, Georgia", CountryCode: "US"},
+		},
+		{
+			name:      "georgia as country in other markets",
+			mkt:       "en-GB",
+			copyright: "Medieval towers in Mestia, Upper Svaneti, Georgia (© photoaliona/Getty Images)",
+			want:      model.Credit{Subject: "Medieval towers in Mestia", Location: "Upper Svaneti", Country: "Georgia", CountryCode: "GE"},
+		},
+		{
+			name:      "georgia followed by country",
+			mkt:       "fr-FR",
+			copyright: "Mousse espagnole le long de la promenade dans le marais d'Okefenokee, Géorgie, États-Unis (© Emmer Photo/Alamy)",
+			want:      model.Credit{Subject: "Mousse espagnole le long de la promenade dans le marais d'Okefenokee", Location: "Géorgie", Country: "États-Unis", CountryCode: "US"},
+		},
+		{
+			name:      "italian state ending with country name",
+			mkt:       "it-IT",
+			copyright: "Parco Nazionale di White Sands, Nuovo Messico (© Andrea Harrell/Tandem Stills + Motion)",
+			want:      model.Credit{Subject: "Parco Nazionale di White Sands", Location: "Nuovo Messico", CountryCode: "US"},
+		},
+		{
+			name:      "italian northern ireland",
+			mkt:       "it-IT",
+			copyright: "Antico faggio, Parco Forestale di Glenariff, Contea di Antrim, Irlanda del Nord (© Dawid K Photography/Shutterstock)",
+			want:      model.Credit{Subject: "Antico faggio", Location: "Parco Forestale di Glenariff, Contea di Antrim", Country: "Irlanda del Nord", CountryCode: "GB"},
+		},
+		{
+			name:      "chinese country in subject",
+			mkt:       "zh-CN",
+			copyright: "加拿大猞猁，蒙大拿州 (© Alan and Sandy Carey/Minden Pictures)",
+			want:      model.Credit{Subject: "加拿大猞猁", Location: "蒙大拿州", CountryCode: "US"},
+		},
+		{
+			name:      "chinese country in location",
+			mkt:       "zh-CN",
+			copyright: "座头鲸家族，荷兰港，阿拉斯加州 (© Jude Newkirk/Amazing Aerial Agency)",
+			want:      model.Credit{Subject: "座头鲸家族", Location: "荷兰港, 阿拉斯加州", CountryCode: "US"},
+		},
+		{
+			name:      "chinese country before state",
+			mkt:       "zh-CN",
+			copyright: "美国犹他州，峡谷地国家公园，在White Rim Road上眺望远方的天际线 (© Alexander Messenger/Tandem Stills + Motion)",
+			want:      model.Credit{Subject: "犹他州", Location: "峡谷地国家公园, 在White Rim Road上眺望远方的天际线", Country: "美国", CountryCode: "US"},
+		},
+		{
+			name:      "chinese country is not japanese",
+			mkt:       "ja-JP",
+			copyright: "日本科学未来館, 東京都 江東区 (© cowardlion/Shutterstock)",
+			want:      model.Credit{Subject: "日本科学未来館", Location: "東京都 江東区", CountryCode: "JP"},
+		},
+		{
+			name:      "japanese country before prefecture",
+			mkt:       "ja-JP",
+			copyright: "グレナリフ森林公園, 北アイルランド アントリム県 (© Dawid K Photography/Shutterstock)",
+			want:      model.Credit{Subject: "グレナリフ森林公園", Location: "アントリム県", Country: "北アイルランド", CountryCode: "GB"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Parse(tt.copyright, tt.mkt)
+			got.Photographer, got.Agency = "", ""
+			if got != tt.want {
+				t.Errorf("Parse(%q, %q)\n got %+v\nwant %+v", tt.copyright, tt.mkt, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseByline(t *testing.T) {
+	got := Parse("Europäischer Flussotter, Lelystad, Niederlande (© Ernst Dirksen/Minden Pictures)", "de-DE")
+	want := model.Credit{
+		Subject:      "Europäischer Flussotter",
+		Location:     "Lelystad",
+		Country:      "Niederlande",
+		CountryCode:  "NL",
+		Photographer: "Ernst Dirksen",
+		Agency:       "Minden Pictures",
+	}
+	if got != want {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+}
@@ -0,0 +1,156 @@
+package copyright
+
+import (
+	"strings"
+	"sync"
+
+	"golang.org/x/text/language"
+	"golang.org/x/text/language/display"
+)
+
+// displayLanguages 各市场描述使用的语言，用其国家名称识别描述中的国家
+var displayLanguages = []language.Tag{
+	language.English,
+	language.SimplifiedChinese,
+	language.German,
+	language.French,
+	language.Italian,
+	language.Japanese,
+}
+
+// countryAliases 标准名称以外的常见写法，以及常单独出现而省略国家的地区，按语言分组
+var countryAliases = map[string]map[string]string{
+	"en": {
+		"usa": "US", "u.s.": "US", "us": "US",
+		"uk": "GB", "england": "GB", "scotland": "GB", "wales": "GB", "northern ireland": "GB",
+		"czech republic": "CZ", "holland": "NL", "the netherlands": "NL", "türkiye": "TR",
+	},
+	"zh": {
+		"美国": "US", "英格兰": "GB", "苏格兰": "GB", "威尔士": "GB", "北爱尔兰": "GB",
+		"中国香港": "HK", "中国台湾": "TW", "台湾": "TW", "南极洲": "AQ",
+	},
+	"de": {
+		"schottland": "GB", "tschechien": "CZ", "holland": "NL",
+	},
+	"fr": {
+		"écosse": "GB", "angleterre": "GB", "pays de galles": "GB", "irlande du nord": "GB", "république tchèque": "CZ", "antarctique": "AQ",
+	},
+	"it": {
+		"stati uniti d'america": "US", "inghilterra": "GB", "scozia": "GB", "galles": "GB", "irlanda del nord": "GB", "regno unito": "GB",
+	},
+	"ja": {
+		"アメリカ": "US", "アメリカ合衆国": "US", "米国": "US",
+		"イングランド": "GB", "スコットランド": "GB", "ウェールズ": "GB", "北アイルランド": "GB", "イギリス": "GB", "英国": "GB",
+		"南極大陸": "AQ",
+	},
+}
+
+var (
+	countriesOnce  sync.Once
+	countries      map[string]string            // 小写国家名称 -> ISO 3166-1 代码，包含所有语言
+	localCountries map[string]map[string]string // 语言 -> 小写国家名称 -> ISO 3166-1 代码
+)
+
+// LookupCountry 按名称识别国家，支持各市场语言的名称，返回 ISO 3166-1 代码
+func LookupCountry(name string) (string, bool) {
+	countriesOnce.Do(loadCountries)
+	code, ok := countries[strings.ToLower(strings.TrimSpace(name))]
+	return code, ok
+}
+
+// lookupLocalCountry 只按指定语言和英文的名称识别国家
+// 其他语言的国家名称可能是描述语言中的普通词，如德语的 Island（冰岛）
+func lookupLocalCountry(name, lang string) (string, bool) {
+	countriesOnce.Do(loadCountries)
+	name = strings.ToLower(strings.TrimSpace(name))
+	if code, ok := localCountries[lang][name]; ok {
+		return code, true
+	}
+	code, ok := localCountries["en"][name]
+	return code, ok
+}
+
+// marketLanguage 市场描述使用的语言，如 de-DE -> de；不支持的语言按英文处理
+func marketLanguage(mkt string) string {
+	base, _ := language.Make(mkt).Base()
+	for _, tag := range displayLanguages {
+		if b, _ := tag.Base(); b == base {
+			return base.String()
+		}
+	}
+	return "en"
+}
+
+// marketCountry 市场所在国家的代码，如 en-US -> US
+func marketCountry(mkt string) string {
+	region, confidence := language.Make(mkt).Region()
+	if confidence != language.Exact {
+		return ""
+	}
+	return region.String()
+}
+
+// CountryCode 校验 ISO 3166-1 代码，返回大写的现行代码，如 uk -> GB
+func CountryCode(code string) (string, bool) {
+	if len(code) != 2 {
+		return "", false
+	}
+	region, err := language.ParseRegion(code)
+	if err != nil || !region.IsCountry() {
+		return "", false
+	}
+	return region.Canonicalize().String(), true
+}
+
+// CountryName 国家代码在指定语言中的名称，如 ("NL", "de-DE") -> Niederlande
+func CountryName(code, lang string) string {
+	region, err := language.ParseRegion(code)
+	if err != nil {
+		return ""
+	}
+	tag, err := language.Parse(lang)
+	if err != nil {
+		tag = language.English
+	}
+	return display.Regions(tag).Name(region)
+}
+
+// loadCountries 由 x/text 的地区名称表生成各语言的国家名称索引
+func loadCountries() {
+	countries = make(map[string]string)
+	localCountries = make(map[string]map[string]string)
+	for _, tag := range displayLanguages {
+		base, _ := tag.Base()
+		localCountries[base.String()] = make(map[string]string)
+	}
+
+	for a := 'A'; a <= 'Z'; a++ {
+		for b := 'A'; b <= 'Z'; b++ {
+			region, err := language.ParseRegion(string([]rune{a, b}))
+			if err != nil || !region.IsCountry() {
+				continue
+			}
+			// 已废弃或保留的代码（如 UK、FX）归到现行代码，同名时保留先出现的
+			code := region.Canonicalize().String()
+			for _, tag := range displayLanguages {
+				name := strings.ToLower(display.Regions(tag).Name(region))
+				if name == "" {
+					continue
+				}
+				if _, exists := countries[name]; !exists {
+					countries[name] = code
+				}
+				base, _ := tag.Base()
+				if local := localCountries[base.String()]; local[name] == "" {
+					local[name] = code
+				}
+			}
+		}
+	}
+	for lang, aliases := range countryAliases {
+		for name, code := range aliases {
+			countries[name] = code
+			localCountries[lang][name] = code
+		}
+	}
+}
@@ -0,0 +1,114 @@
+package copyright
+
+import "strings"
+
+// regions 常在省略国家时单独出现的一级行政区，用于推断国家代码
+// 本地市场的描述通常只写到州或省，如 "Alstrom Point, Lake Powell, Utah"
+var regions = map[string][]string{
+	"US": {
+		"alabama", "alaska", "arizona", "arkansas", "california", "colorado", "connecticut", "delaware",
+		"florida", "georgia", "hawaii", "idaho", "illinois", "indiana", "iowa", "kansas", "kentucky", "louisiana",
+		"maine", "maryland", "massachusetts", "michigan", "minnesota", "mississippi", "missouri", "montana",
+		"nebraska", "nevada", "new hampshire", "new jersey", "new mexico", "new york", "north carolina",
+		"north dakota", "ohio", "oklahoma", "oregon", "pennsylvania", "rhode island", "south carolina",
+		"south dakota", "tennessee", "texas", "utah", "vermont", "virginia", "washington", "west virginia",
+		"wisconsin", "wyoming", "new york city", "dc", "washington dc", "washington, dc", "puerto rico",
+		"kalifornien", "kalifornia", "californie", "floride", "hawaï", "louisiane", "pennsylvanie",
+		"virginie", "caroline du nord", "caroline du sud", "nouveau-mexique", "nouveau mexique", "géorgie",
+		"carolina del nord", "carolina del sud", "nuovo messico",
+		"加利福尼亚", "加州", "阿拉斯加", "科罗拉多", "犹他", "亚利桑那", "怀俄明", "蒙大拿", "华盛顿",
+		"俄勒冈", "夏威夷", "纽约", "缅因", "密歇根", "佛罗里达", "德克萨斯", "内华达", "新墨西哥", "爱达荷",
+		"北卡罗来纳", "南达科他", "田纳西", "弗吉尼亚", "宾夕法尼亚", "马萨诸塞", "威斯康星", "明尼苏达",
+	},
+	"CA": {
+		"alberta", "british columbia", "manitoba", "new brunswick", "newfoundland and labrador",
+		"nova scotia", "ontario", "prince edward island", "quebec", "québec", "saskatchewan", "yukon",
+		"northwest territories", "nunavut", "colombie-britannique", "nouvelle-écosse", "britisch-kolumbien",
+		"不列颠哥伦比亚", "魁北克", "安大略", "艾伯塔", "育空", "新斯科舍", "纽芬兰与拉布拉多",
+	},
+	"AU": {
+		"new south wales", "queensland", "victoria", "tasmania", "western australia", "south australia",
+		"northern territory", "新南威尔士", "昆士兰", "维多利亚", "塔斯马尼亚", "西澳大利亚", "南澳大利亚", "北领地",
+	},
+	"JP": {
+		"東京", "京都", "大阪", "沖縄", "hokkaido", "okinawa",
+	},
+	"IN": {
+		"andhra pradesh", "arunachal pradesh", "assam", "bihar", "chhattisgarh", "goa", "gujarat", "haryana",
+		"himachal pradesh", "jharkhand", "karnataka", "kerala", "madhya pradesh", "maharashtra", "manipur",
+		"meghalaya", "mizoram", "nagaland", "odisha", "punjab", "rajasthan", "sikkim", "tamil nadu",
+		"telangana", "tripura", "uttar pradesh", "uttarakhand", "west bengal", "ladakh", "jammu and kashmir",
+		"new delhi", "delhi",
+	},
+	"DE": {
+		"baden-württemberg", "bayern", "bavaria", "berlin", "brandenburg", "bremen", "hamburg", "hessen",
+		"mecklenburg-vorpommern", "niedersachsen", "nordrhein-westfalen", "rheinland-pfalz", "saarland",
+		"sachsen", "sachsen-anhalt", "schleswig-holstein", "thüringen", "monaco di baviera",
+	},
+	"IT": {
+		"abruzzo", "basilicata", "calabria", "campania", "emilia-romagna", "friuli-venezia giulia", "lazio",
+		"liguria", "lombardia", "marche", "molise", "piemonte", "puglia", "sardegna", "sicilia", "toscana",
+		"trentino-alto adige", "umbria", "valle d'aosta", "veneto", "alto adige",
+	},
+	"FR": {
+		"auvergne-rhône-alpes", "bourgogne-franche-comté", "bretagne", "centre-val de loire", "corse",
+		"grand est", "hauts-de-france", "île-de-france", "normandie", "nouvelle-aquitaine", "occitanie",
+		"pays de la loire", "provence-alpes-côte d'azur", "paris",
+	},
+	"GB": {
+		"london", "cornwall", "yorkshire", "north yorkshire", "devon", "kent", "cumbria", "norfolk",
+		"northumberland", "dorset", "highlands", "west highlands", "isle of skye",
+	},
+}
+
+// japanesePrefectureSuffixes 日本都道府县名称的后缀，如 埼玉県、北海道、東京都、京都府
+var japanesePrefectureSuffixes = []string{"県", "都", "府", "道"}
+
+var regionIndex = func() map[string]string {
+	index := make(map[string]string)
+	for code, names := range regions {
+		for _, name := range names {
+			index[name] = code
+		}
+	}
+	return index
+}()
+
+// lookupRegion 按一级行政区推断国家代码，中文名称可带“州”“省”后缀
+func lookupRegion(name string) (string, bool) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if code, ok := regionIndex[name]; ok {
+		return code, true
+	}
+	for _, suffix := range []string{"州", "省"} {
+		if trimmed := strings.TrimSuffix(name, suffix); trimmed != name {
+			if code, ok := regionIndex[trimmed]; ok {
+				return code, true
+			}
+		}
+	}
+	return "", false
+}
+
+// isRegionName 是否为一级行政区名称，包括未收录的中文省、州名，如 吉林省、伊达尔戈州
+func isRegionName(name string) bool {
+	if _, ok := lookupRegion(name); ok {
+		return true
+	}
+	runes := []rune(name)
+	return len(runes) >= 3 && (strings.HasSuffix(name, "省") || strings.HasSuffix(name, "州"))
+}
+
+// isJapanesePrefecture 是否为日本都道府县名称，如 埼玉県、北海道
+func isJapanesePrefecture(name string) bool {
+	runes := []rune(name)
+	if len(runes) < 3 || len(runes) > 4 {
+		return false
+	}
+	for _, suffix := range japanesePrefectureSuffixes {
+		if strings.HasSuffix(name, suffix) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,49 @@
+package database
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/gclm/galaxy-bing-wallpapers/pkg/model"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// ArchiveCounts 按年、月、市场统计符合条件的壁纸数量，按年、月倒序，同月按市场排序
+func ArchiveCounts(ctx context.Context, filter bson.M) ([]model.ArchiveCount, error) {
+	collection := GetCollection("wallpapers")
+
+	cursor, err := collection.Aggregate(ctx, mongo.Pipeline{
+		{{Key: "$match", Value: filter}},
+		{{Key: "$group", Value: bson.M{
+			"_id": bson.M{
+				"year":  bson.M{"$substrBytes": bson.A{"$datetime", 0, 4}},
+				"month": bson.M{"$substrBytes": bson.A{"$datetime", 5, 2}},
+				"mkt":   "$mkt",
+			},
+			"count": bson.M{"$sum": 1},
+		}}},
+		{{Key: "$project", Value: bson.M{
+			"_id":   0,
+			"year":  bson.M{"$toInt": "$_id.year"},
+			"month": bson.M{"$toInt": "$_id.month"},
+			"mkt":   "$_id.mkt",
+			"count": 1,
+		}}},
+		{{Key: "$sort", Value: bson.D{
+			{Key: "year", Value: -1},
+			{Key: "month", Value: -1},
+			{Key: "mkt", Value: 1},
+		}}},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to aggregate wallpapers: %v", err)
+	}
+	defer cursor.Close(ctx)
+
+	var counts []model.ArchiveCount
+	if err := cursor.All(ctx, &counts); err != nil {
+		return nil, fmt.Errorf("failed to decode archive counts: %v", err)
+	}
+	return counts, nil
+}
@@ -0,0 +1,109 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/gclm/galaxy-bing-wallpapers/pkg/model"
+)
+
+// BulkResult 批量写入结果
+type BulkResult struct {
+	Inserted int            // 新插入的数量
+	Matched  int            // 已存在而未写入的数量
+	Failed   map[int]string // 写入失败的记录（按输入下标）及原因
+}
+
+// MaxWallpaperID 获取当前最大的壁纸 ID，没有数据时返回 0
+func MaxWallpaperID(ctx context.Context) (int, error) {
+	collection := GetCollection("wallpapers")
+
+	var last model.Wallpaper
+	err := collection.FindOne(ctx, bson.M{},
+		options.FindOne().SetSort(bson.D{{Key: "id", Value: -1}})).Decode(&last)
+	if err == mongo.ErrNoDocuments {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to get last wallpaper: %v", err)
+	}
+	return last.ID, nil
+}
+
+// ExistingDatetimes 查询指定市场中已存在的日期
+func ExistingDatetimes(ctx context.Context, mkt string, datetimes []string) (map[string]bool, error) {
+	collection := GetCollection("wallpapers")
+
+	cursor, err := collection.Find(ctx,
+		bson.M{"mkt": mkt, "datetime": bson.M{"$in": datetimes}},
+		options.Find().SetProjection(bson.M{"datetime": 1}))
+	if err != nil {
+		return nil, fmt.Errorf("failed to query existing wallpapers: %v", err)
+	}
+	defer cursor.Close(ctx)
+
+	var rows []struct {
+		Datetime string `bson:"datetime"`
+	}
+	if err := cursor.All(ctx, &rows); err != nil {
+		return nil, fmt.Errorf("failed to decode existing wallpapers: %v", err)
+	}
+
+	existing := make(map[string]bool, len(rows))
+	for _, row := range rows {
+		existing[row.Datetime] = true
+	}
+	return existing, nil
+}
+
+// BulkUpsertWallpapers 按 datetime+mkt 批量插入壁纸，已存在的记录保持不变
+// 单条失败（如 ID 冲突）不影响其他记录，失败原因记录在 BulkResult.Failed 中
+func BulkUpsertWallpapers(ctx context.Context, wallpapers []model.Wallpaper) (*BulkResult, error) {
+	result := &BulkResult{Failed: make(map[int]string)}
+	if len(wallpapers) == 0 {
+		return result, nil
+	}
+
+	models := make([]mongo.WriteModel, len(wallpapers))
+	for i, wallpaper := range wallpapers {
+		fillDerived(&wallpaper)
+		models[i] = mongo.NewUpdateOneModel().
+			SetFilter(bson.M{"datetime": wallpaper.Datetime, "mkt": wallpaper.Mkt}).
+			SetUpdate(bson.M{"$setOnInsert": wallpaper}).
+			SetUpsert(true)
+	}
+
+	collection := GetCollection("wallpapers")
+	res, err := collection.BulkWrite(ctx, models, options.BulkWrite().SetOrdered(false))
+	if res != nil {
+		result.Inserted = int(res.UpsertedCount)
+		result.Matched = int(res.MatchedCount)
+	}
+
+	var bulkErr mongo.BulkWriteException
+	if errors.As(err, &bulkErr) && bulkErr.WriteConcernError == nil {
+		for _, writeErr := range bulkErr.WriteErrors {
+			if writeErr.Code == 11000 {
+				result.Failed[writeErr.Index] = "duplicate key"
+			} else {
+				result.Failed[writeErr.Index] = writeErr.Message
+			}
+		}
+		err = nil
+	}
+	// 部分写入失败时也可能已有新记录
+	if result.Inserted > 0 {
+		if bumpErr := BumpDataVersion(ctx); bumpErr != nil && err == nil {
+			return result, bumpErr
+		}
+	}
+	if err != nil {
+		return result, fmt.Errorf("failed to bulk write wallpapers: %v", err)
+	}
+	return result, nil
+}
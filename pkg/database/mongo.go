@@ -7,10 +7,13 @@ import (
 	"time"
 
 	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
 
 	"github.com/gclm/galaxy-bing-wallpapers/pkg/config"
+	"github.com/gclm/galaxy-bing-wallpapers/pkg/copyright"
+	"github.com/gclm/galaxy-bing-wallpapers/pkg/geo"
 	"github.com/gclm/galaxy-bing-wallpapers/pkg/model"
 )
 
@@ -71,10 +74,14 @@ func SaveWallpaper(wallpaper model.Wallpaper) error {
 		}
 
 		// 插入新记录
+		fillDerived(&wallpaper)
 		_, err = collection.InsertOne(ctx, wallpaper)
 		if err != nil {
 			return fmt.Errorf("failed to insert wallpaper: %v", err)
 		}
+		if err := BumpDataVersion(ctx); err != nil {
+			return err
+		}
 
 		log.Printf("Inserted new wallpaper: ID=%d, Title=%s", wallpaper.ID, wallpaper.Title)
 	}
@@ -82,50 +89,173 @@ func SaveWallpaper(wallpaper model.Wallpaper) error {
 	return nil
 }
 
-// CreateIndexes 创建必要的数据库索引
-func CreateIndexes() error {
+// fillDerived 填充由原始字段推导的字段：图片标识、署名信息和坐标，已设置的保持不变
+func fillDerived(wallpaper *model.Wallpaper) {
+	if wallpaper.OHR == "" {
+		wallpaper.OHR = model.ParseOHR(wallpaper.Url)
+	}
+	if wallpaper.Credit == nil {
+		credit := copyright.Parse(wallpaper.Copyright, wallpaper.Mkt)
+		wallpaper.Credit = &credit
+	}
+	if wallpaper.Geo == nil {
+		wallpaper.Geo = geo.Locate(*wallpaper.Credit)
+	}
+}
+
+// WallpaperExists 检查壁纸是否已存在
+func WallpaperExists(datetime, mkt string) (bool, error) {
 	collection := GetCollection("wallpapers")
 	ctx := context.Background()
 
-	// 创建ID唯一索引
-	_, err := collection.Indexes().CreateOne(ctx, mongo.IndexModel{
-		Keys:    bson.D{{Key: "id", Value: 1}},
-		Options: options.Index().SetUnique(true),
+	count, err := collection.CountDocuments(ctx, bson.M{
+		"datetime": datetime,
+		"mkt":      mkt,
 	})
 
 	if err != nil {
-		return fmt.Errorf("failed to create id index: %v", err)
+		return false, err
 	}
 
-	// 创建日期和市场代码复合索引
-	_, err = collection.Indexes().CreateOne(ctx, mongo.IndexModel{
-		Keys: bson.D{
-			{Key: "datetime", Value: 1},
-			{Key: "mkt", Value: 1},
-		},
-		Options: options.Index().SetUnique(true),
-	})
+	return count > 0, nil
+}
+
+// Ping 检查数据库连接
+func Ping(ctx context.Context) error {
+	if Client == nil {
+		return fmt.Errorf("MongoDB client is not initialized")
+	}
+	return Client.Ping(ctx, nil)
+}
 
+// RecordFetch 记录一次壁纸抓取结果
+func RecordFetch(fetchLog model.FetchLog) error {
+	collection := GetCollection("fetch_logs")
+	ctx := context.Background()
+
+	if fetchLog.FetchedAt.IsZero() {
+		fetchLog.FetchedAt = time.Now().UTC()
+	}
+
+	if _, err := collection.InsertOne(ctx, fetchLog); err != nil {
+		return fmt.Errorf("failed to insert fetch log: %v", err)
+	}
+	return BumpDataVersion(ctx)
+}
+
+// LastSuccessfulFetches 获取各市场最近一次成功抓取的时间
+func LastSuccessfulFetches(ctx context.Context) (map[string]time.Time, error) {
+	collection := GetCollection("fetch_logs")
+
+	cursor, err := collection.Aggregate(ctx, mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"success": true}}},
+		{{Key: "$group", Value: bson.M{
+			"_id":  "$mkt",
+			"last": bson.M{"$max": "$fetched_at"},
+		}}},
+	})
 	if err != nil {
-		return fmt.Errorf("failed to create datetime-mkt index: %v", err)
+		return nil, fmt.Errorf("failed to aggregate fetch logs: %v", err)
 	}
+	defer cursor.Close(ctx)
 
-	return nil
+	var rows []struct {
+		Mkt  string    `bson:"_id"`
+		Last time.Time `bson:"last"`
+	}
+	if err := cursor.All(ctx, &rows); err != nil {
+		return nil, fmt.Errorf("failed to decode fetch logs: %v", err)
+	}
+
+	result := make(map[string]time.Time, len(rows))
+	for _, row := range rows {
+		result[row.Mkt] = row.Last
+	}
+	return result, nil
 }
 
-// WallpaperExists 检查壁纸是否已存在
-func WallpaperExists(datetime, mkt string) (bool, error) {
+// LatestWallpaperDates 获取各市场最新壁纸的日期（YYYY-MM-DD）
+func LatestWallpaperDates(ctx context.Context) (map[string]string, error) {
 	collection := GetCollection("wallpapers")
-	ctx := context.Background()
 
-	count, err := collection.CountDocuments(ctx, bson.M{
-		"datetime": datetime,
-		"mkt":      mkt,
+	cursor, err := collection.Aggregate(ctx, mongo.Pipeline{
+		{{Key: "$group", Value: bson.M{
+			"_id":  "$mkt",
+			"last": bson.M{"$max": "$datetime"},
+		}}},
 	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to aggregate wallpapers: %v", err)
+	}
+	defer cursor.Close(ctx)
 
+	var rows []struct {
+		Mkt  string `bson:"_id"`
+		Last string `bson:"last"`
+	}
+	if err := cursor.All(ctx, &rows); err != nil {
+		return nil, fmt.Errorf("failed to decode wallpapers: %v", err)
+	}
+
+	result := make(map[string]string, len(rows))
+	for _, row := range rows {
+		result[row.Mkt] = row.Last
+	}
+	return result, nil
+}
+
+// ListWallpapers 按条件查询全部壁纸
+func ListWallpapers(ctx context.Context, filter bson.M, sort bson.D) ([]model.Wallpaper, error) {
+	collection := GetCollection("wallpapers")
+
+	cursor, err := collection.Find(ctx, filter, options.Find().SetSort(sort))
 	if err != nil {
-		return false, err
+		return nil, fmt.Errorf("failed to query wallpapers: %v", err)
 	}
+	defer cursor.Close(ctx)
 
-	return count > 0, nil
+	var wallpapers []model.Wallpaper
+	if err := cursor.All(ctx, &wallpapers); err != nil {
+		return nil, fmt.Errorf("failed to decode wallpapers: %v", err)
+	}
+	return wallpapers, nil
+}
+
+// StoredWallpaper 带有文档 _id 的壁纸，id 等字段重复时用 _id 定位到具体的一条记录
+type StoredWallpaper struct {
+	ObjectID        primitive.ObjectID `bson:"_id"`
+	model.Wallpaper `bson:",inline"`
+}
+
+// ListStoredWallpapers 按条件查询全部壁纸及其 _id
+func ListStoredWallpapers(ctx context.Context, filter bson.M, sort bson.D) ([]StoredWallpaper, error) {
+	collection := GetCollection("wallpapers")
+
+	cursor, err := collection.Find(ctx, filter, options.Find().SetSort(sort))
+	if err != nil {
+		return nil, fmt.Errorf("failed to query wallpapers: %v", err)
+	}
+	defer cursor.Close(ctx)
+
+	var wallpapers []StoredWallpaper
+	if err := cursor.All(ctx, &wallpapers); err != nil {
+		return nil, fmt.Errorf("failed to decode wallpapers: %v", err)
+	}
+	return wallpapers, nil
+}
+
+// UpdateWallpapers 按条件更新壁纸，返回修改的数量
+func UpdateWallpapers(ctx context.Context, filter bson.M, update bson.M) (int64, error) {
+	collection := GetCollection("wallpapers")
+
+	result, err := collection.UpdateMany(ctx, filter, update)
+	if err != nil {
+		return 0, fmt.Errorf("failed to update wallpapers: %v", err)
+	}
+	if result.ModifiedCount > 0 {
+		if err := BumpDataVersion(ctx); err != nil {
+			return result.ModifiedCount, err
+		}
+	}
+	return result.ModifiedCount, nil
 }
@@ -0,0 +1,132 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/gclm/galaxy-bing-wallpapers/pkg/model"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// RandomHistoryCollection 记录随机接口返回历史的集合，served_at 上有 TTL 索引
+const RandomHistoryCollection = "random_history"
+
+// sampleOversample 多张随机时先多取的倍数，按图片去重后再取所需数量
+const sampleOversample = 10
+
+// SampleWallpapers 使用 $sample 从符合条件的壁纸中随机取 size 张（互不相同）
+// 同一张图片在多个市场的版本（ohr 相同）只返回其中一个
+func SampleWallpapers(ctx context.Context, filter bson.M, size int) ([]model.Wallpaper, error) {
+	collection := GetCollection("wallpapers")
+
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: filter}},
+		{{Key: "$sample", Value: bson.M{"size": size}}},
+	}
+	if size > 1 {
+		pipeline = mongo.Pipeline{
+			{{Key: "$match", Value: filter}},
+			{{Key: "$sample", Value: bson.M{"size": size * sampleOversample}}},
+			{{Key: "$group", Value: bson.M{
+				"_id": bson.M{"$ifNull": bson.A{"$ohr", "$id"}},
+				"doc": bson.M{"$first": "$$ROOT"},
+			}}},
+			{{Key: "$replaceRoot", Value: bson.M{"newRoot": "$doc"}}},
+			{{Key: "$sample", Value: bson.M{"size": size}}},
+		}
+	}
+
+	cursor, err := collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sample wallpapers: %v", err)
+	}
+	defer cursor.Close(ctx)
+
+	var wallpapers []model.Wallpaper
+	if err := cursor.All(ctx, &wallpapers); err != nil {
+		return nil, fmt.Errorf("failed to decode wallpapers: %v", err)
+	}
+	return wallpapers, nil
+}
+
+// CountWallpapers 符合条件的壁纸数量
+func CountWallpapers(ctx context.Context, filter bson.M) (int64, error) {
+	count, err := GetCollection("wallpapers").CountDocuments(ctx, filter)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count wallpapers: %v", err)
+	}
+	return count, nil
+}
+
+// WallpaperAt 符合条件的壁纸按 ID 升序排列后的第 offset 张（从 0 开始），不存在时返回 nil
+func WallpaperAt(ctx context.Context, filter bson.M, offset int64) (*model.Wallpaper, error) {
+	opts := options.FindOne().
+		SetSort(bson.D{{Key: "id", Value: 1}}).
+		SetSkip(offset)
+
+	var wallpaper model.Wallpaper
+	err := GetCollection("wallpapers").FindOne(ctx, filter, opts).Decode(&wallpaper)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to find wallpaper: %v", err)
+	}
+	return &wallpaper, nil
+}
+
+// RecentRandomPicks 获取客户端在 since 之后已经拿到过的壁纸 ID 和图片标识
+func RecentRandomPicks(ctx context.Context, client string, since time.Time) ([]int, []string, error) {
+	collection := GetCollection(RandomHistoryCollection)
+	filter := bson.M{
+		"client":    client,
+		"served_at": bson.M{"$gte": since},
+	}
+
+	values, err := collection.Distinct(ctx, "wallpaper_id", filter)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read random history: %v", err)
+	}
+	ids := make([]int, 0, len(values))
+	for _, value := range values {
+		switch id := value.(type) {
+		case int32:
+			ids = append(ids, int(id))
+		case int64:
+			ids = append(ids, int(id))
+		}
+	}
+
+	values, err = collection.Distinct(ctx, "ohr", filter)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read random history: %v", err)
+	}
+	ohrs := make([]string, 0, len(values))
+	for _, value := range values {
+		if ohr, ok := value.(string); ok && ohr != "" {
+			ohrs = append(ohrs, ohr)
+		}
+	}
+	return ids, ohrs, nil
+}
+
+// RecordRandomPicks 记录返回给客户端的壁纸
+func RecordRandomPicks(ctx context.Context, client string, wallpapers []model.Wallpaper) error {
+	if len(wallpapers) == 0 {
+		return nil
+	}
+
+	now := time.Now().UTC()
+	docs := make([]interface{}, len(wallpapers))
+	for i, w := range wallpapers {
+		docs[i] = model.RandomPick{Client: client, WallpaperID: w.ID, OHR: w.OHR, ServedAt: now}
+	}
+
+	if _, err := GetCollection(RandomHistoryCollection).InsertMany(ctx, docs); err != nil {
+		return fmt.Errorf("failed to record random history: %v", err)
+	}
+	return nil
+}
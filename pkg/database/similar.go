@@ -0,0 +1,42 @@
+package database
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/gclm/galaxy-bing-wallpapers/pkg/model"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// WallpaperHash 壁纸 ID、市场、图片标识和感知哈希，用于在内存中比较相似度
+type WallpaperHash struct {
+	ID     int                `bson:"id"`
+	Mkt    string             `bson:"mkt"`
+	OHR    string             `bson:"ohr"`
+	Hashes *model.ImageHashes `bson:"hashes"`
+}
+
+// WallpaperHashes 获取符合条件且已计算感知哈希的壁纸，按 ID 升序
+func WallpaperHashes(ctx context.Context, filter bson.M) ([]WallpaperHash, error) {
+	collection := GetCollection("wallpapers")
+
+	query := bson.M{"hashes": bson.M{"$exists": true}}
+	for key, value := range filter {
+		query[key] = value
+	}
+	opts := options.Find().
+		SetProjection(bson.M{"_id": 0, "id": 1, "mkt": 1, "ohr": 1, "hashes": 1}).
+		SetSort(bson.D{{Key: "id", Value: 1}})
+	cursor, err := collection.Find(ctx, query, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list wallpaper hashes: %v", err)
+	}
+	defer cursor.Close(ctx)
+
+	var hashes []WallpaperHash
+	if err := cursor.All(ctx, &hashes); err != nil {
+		return nil, fmt.Errorf("failed to decode wallpaper hashes: %v", err)
+	}
+	return hashes, nil
+}
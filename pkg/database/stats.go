@@ -0,0 +1,97 @@
+package database
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/gclm/galaxy-bing-wallpapers/pkg/model"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// WallpaperStats 壁纸集合的聚合统计，覆盖率等派生字段由调用方计算
+type WallpaperStats struct {
+	Markets       []model.MarketStats `bson:"markets"`
+	Agencies      []model.NameCount   `bson:"agencies"`
+	Photographers []model.NameCount   `bson:"photographers"`
+	Reuse         []model.ReuseBucket `bson:"reuse"`
+}
+
+// imageKey 按图片归并时使用的键，没有 OHR 标识的壁纸单独算一张
+var imageKey = bson.M{"$ifNull": bson.A{"$ohr", "$id"}}
+
+// AggregateWallpaperStats 用一次 $facet 聚合统计各市场数量和日期范围、图片数量最多的图片库和摄影师、图片跨市场复用分布
+// 图片库和摄影师按不同图片计数，同一张图片在多个市场使用只算一次，各取前 top 个
+func AggregateWallpaperStats(ctx context.Context, top int) (*WallpaperStats, error) {
+	collection := GetCollection("wallpapers")
+
+	cursor, err := collection.Aggregate(ctx, mongo.Pipeline{
+		{{Key: "$facet", Value: bson.M{
+			"markets": bson.A{
+				bson.M{"$group": bson.M{
+					"_id":   "$mkt",
+					"count": bson.M{"$sum": 1},
+					"first": bson.M{"$min": "$datetime"},
+					"last":  bson.M{"$max": "$datetime"},
+				}},
+			},
+			"agencies":      topByImages("credit.agency", top),
+			"photographers": topByImages("credit.photographer", top),
+			"reuse": bson.A{
+				bson.M{"$group": bson.M{"_id": imageKey, "markets": bson.M{"$addToSet": "$mkt"}}},
+				bson.M{"$group": bson.M{"_id": bson.M{"$size": "$markets"}, "images": bson.M{"$sum": 1}}},
+				bson.M{"$sort": bson.M{"_id": 1}},
+			},
+		}}},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to aggregate wallpaper stats: %v", err)
+	}
+	defer cursor.Close(ctx)
+
+	var stats []WallpaperStats
+	if err := cursor.All(ctx, &stats); err != nil {
+		return nil, fmt.Errorf("failed to decode wallpaper stats: %v", err)
+	}
+	if len(stats) == 0 {
+		return &WallpaperStats{}, nil
+	}
+	return &stats[0], nil
+}
+
+// topByImages 按字段统计不同图片的数量，忽略缺少该字段的壁纸，取前 top 个
+func topByImages(field string, top int) bson.A {
+	return bson.A{
+		bson.M{"$match": bson.M{field: bson.M{"$nin": bson.A{nil, ""}}}},
+		bson.M{"$group": bson.M{"_id": bson.M{"name": "$" + field, "image": imageKey}}},
+		bson.M{"$group": bson.M{"_id": "$_id.name", "count": bson.M{"$sum": 1}}},
+		bson.M{"$sort": bson.D{{Key: "count", Value: -1}, {Key: "_id", Value: 1}}},
+		bson.M{"$limit": top},
+	}
+}
+
+// AggregateFetchStats 按市场统计抓取次数、成功次数和最近一次成功、失败的时间
+func AggregateFetchStats(ctx context.Context) ([]model.FetchStats, error) {
+	collection := GetCollection("fetch_logs")
+
+	cursor, err := collection.Aggregate(ctx, mongo.Pipeline{
+		{{Key: "$group", Value: bson.M{
+			"_id":          "$mkt",
+			"attempts":     bson.M{"$sum": 1},
+			"successes":    bson.M{"$sum": bson.M{"$cond": bson.A{"$success", 1, 0}}},
+			"new":          bson.M{"$sum": bson.M{"$cond": bson.A{"$is_new", 1, 0}}},
+			"last_success": bson.M{"$max": bson.M{"$cond": bson.A{"$success", "$fetched_at", nil}}},
+			"last_failure": bson.M{"$max": bson.M{"$cond": bson.A{"$success", nil, "$fetched_at"}}},
+		}}},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to aggregate fetch logs: %v", err)
+	}
+	defer cursor.Close(ctx)
+
+	var stats []model.FetchStats
+	if err := cursor.All(ctx, &stats); err != nil {
+		return nil, fmt.Errorf("failed to decode fetch stats: %v", err)
+	}
+	return stats, nil
+}
@@ -0,0 +1,41 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// MetaCollection 保存数据版本等元信息的集合
+const MetaCollection = "meta"
+
+// dataVersionID 数据版本文档的 _id
+const dataVersionID = "data_version"
+
+// BumpDataVersion 递增数据版本，每次写入壁纸或抓取记录（包括迁移、回填、修复和恢复对已有记录的修改）后调用
+func BumpDataVersion(ctx context.Context) error {
+	_, err := GetCollection(MetaCollection).UpdateOne(ctx,
+		bson.M{"_id": dataVersionID},
+		bson.M{"$inc": bson.M{"version": 1}, "$currentDate": bson.M{"updated_at": true}},
+		options.Update().SetUpsert(true))
+	if err != nil {
+		return fmt.Errorf("failed to bump data version: %v", err)
+	}
+	return nil
+}
+
+// DataVersion 当前的数据版本，用于让缓存的统计结果失效；从未写入过时为 0
+func DataVersion(ctx context.Context) (string, error) {
+	var doc struct {
+		Version int64 `bson:"version"`
+	}
+	err := GetCollection(MetaCollection).FindOne(ctx, bson.M{"_id": dataVersionID}).Decode(&doc)
+	if err != nil && err != mongo.ErrNoDocuments {
+		return "", fmt.Errorf("failed to get data version: %v", err)
+	}
+	return strconv.FormatInt(doc.Version, 10), nil
+}
@@ -0,0 +1,127 @@
+package docs
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/gclm/galaxy-bing-wallpapers/pkg/handler"
+	"github.com/gclm/galaxy-bing-wallpapers/pkg/version"
+	"github.com/gin-gonic/gin"
+)
+
+//go:embed openapi.json
+var openAPISpec []byte
+
+var (
+	specOnce sync.Once
+	specJSON []byte
+	specErr  error
+)
+
+// redocVersion 文档页面使用的 Redoc 版本，固定版本避免 CDN 上的新版本改变页面
+const redocVersion = "2.1.5"
+
+// redocPage 交互式文档页面，由 Redoc 渲染 /openapi.json
+const redocPage = `<!DOCTYPE html>
+<html>
+<head>
+  <meta charset="utf-8">
+  <meta name="viewport" content="width=device-width, initial-scale=1">
+  <title>Galaxy Bing Wallpapers API</title>
+  <style>body { margin: 0; padding: 0; }</style>
+</head>
+<body>
+  <redoc spec-url="openapi.json"></redoc>
+  <script src="https://cdn.jsdelivr.net/npm/redoc@` + redocVersion + `/bundles/redoc.standalone.js"></script>
+</body>
+</html>
+`
+
+// Spec 获取 OpenAPI 文档，info.version 使用构建时注入的版本号
+func Spec() ([]byte, error) {
+	specOnce.Do(func() {
+		var spec map[string]interface{}
+		if specErr = json.Unmarshal(openAPISpec, &spec); specErr != nil {
+			return
+		}
+		if info, ok := spec["info"].(map[string]interface{}); ok {
+			info["version"] = version.Version
+		}
+		specJSON, specErr = json.Marshal(spec)
+	})
+	return specJSON, specErr
+}
+
+// OpenAPI 返回 OpenAPI 文档
+func OpenAPI(c *gin.Context) {
+	spec, err := Spec()
+	if err != nil {
+		handler.HandleError(c, err)
+		return
+	}
+	c.Data(http.StatusOK, "application/json; charset=utf-8", spec)
+}
+
+// Redoc 返回交互式文档页面
+func Redoc(c *gin.Context) {
+	c.Data(http.StatusOK, "text/html; charset=utf-8", []byte(redocPage))
+}
+
+// CheckRoutes 检查 OpenAPI 文档与已注册路由是否一致
+// 返回文档中缺少的路由和文档中多余的路由
+func CheckRoutes(routes gin.RoutesInfo) error {
+	var spec struct {
+		Paths map[string]map[string]json.RawMessage `json:"paths"`
+	}
+	if err := json.Unmarshal(openAPISpec, &spec); err != nil {
+		return fmt.Errorf("failed to parse openapi.json: %v", err)
+	}
+
+	documented := make(map[string]bool)
+	for path, item := range spec.Paths {
+		for method := range item {
+			documented[strings.ToUpper(method)+" "+path] = true
+		}
+	}
+
+	registered := make(map[string]bool)
+	for _, route := range routes {
+		registered[route.Method+" "+toOpenAPIPath(route.Path)] = true
+	}
+
+	var missing, extra []string
+	for route := range registered {
+		if !documented[route] {
+			missing = append(missing, route)
+		}
+	}
+	for route := range documented {
+		if !registered[route] {
+			extra = append(extra, route)
+		}
+	}
+
+	if len(missing) == 0 && len(extra) == 0 {
+		return nil
+	}
+
+	sort.Strings(missing)
+	sort.Strings(extra)
+	return fmt.Errorf("openapi.json does not match registered routes: undocumented %v, not registered %v", missing, extra)
+}
+
+// toOpenAPIPath 将 gin 路径参数转换为 OpenAPI 格式，如 /date/:date 转为 /date/{date}
+func toOpenAPIPath(path string) string {
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		if strings.HasPrefix(segment, ":") || strings.HasPrefix(segment, "*") {
+			segments[i] = "{" + segment[1:] + "}"
+		}
+	}
+	return strings.Join(segments, "/")
+}
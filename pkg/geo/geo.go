@@ -0,0 +1,230 @@
+// Package geo 用内置的离线地名表为壁纸标注国家、地区和近似坐标
+//
+// 地名表 gazetteer.tsv 编译进二进制，不依赖外部地理编码服务。
+// 定位只使用 pkg/copyright 解析出的字段：先在国家范围内匹配地点和地区名称，
+// 都没有匹配时退回到国家的几何中心。
+package geo
+
+import (
+	_ "embed"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/gclm/galaxy-bing-wallpapers/pkg/model"
+)
+
+// 定位精度
+const (
+	PrecisionPlace   = "place"   // 具体地点，如国家公园、城市
+	PrecisionRegion  = "region"  // 一级行政区或大区域，如州、省、岛屿
+	PrecisionCountry = "country" // 只知道国家
+)
+
+// minSubjectRunes 在主题中按子串匹配中日文名称时的最小长度，避免“三重塔”之类的误匹配
+const minSubjectRunes = 3
+
+//go:embed gazetteer.tsv
+var gazetteerData string
+
+// entry 地名表中的一行
+type entry struct {
+	country string
+	kind    string
+	lat     float64
+	lon     float64
+	names   []string // 小写，第一个为规范名称
+	display string   // 规范名称的原始写法
+}
+
+var (
+	loadOnce  sync.Once
+	countries map[string]entry   // 国家代码 -> 国家中心
+	places    map[string][]entry // 国家代码 -> 地区和地点
+)
+
+// Locate 按署名信息中的地点定位，无法定位时返回 nil
+func Locate(credit model.Credit) *model.Geo {
+	loadOnce.Do(load)
+
+	country := credit.CountryCode
+	if country == "" {
+		// 没有国家时只接受在所有国家中唯一的地区或地点名称
+		e, ok := uniqueMatch(splitLocation(credit.Location))
+		if !ok {
+			return nil
+		}
+		return e.geo()
+	}
+
+	candidates := places[country]
+	texts := []struct {
+		text     string
+		minRunes int
+	}{
+		{credit.Location, 1},
+		{credit.Subject, minSubjectRunes},
+		{credit.Country, 1}, // 如 Scotland 识别为 GB，但本身就是地区
+	}
+	for _, t := range texts {
+		if e, ok := bestMatch(candidates, strings.ToLower(t.text), t.minRunes); ok {
+			return e.geo()
+		}
+	}
+
+	if e, ok := countries[country]; ok {
+		return e.geo()
+	}
+	return nil
+}
+
+// geo 转换为壁纸的地理信息
+func (e entry) geo() *model.Geo {
+	g := &model.Geo{
+		CountryCode: e.country,
+		Precision:   e.kind,
+		Lat:         e.lat,
+		Lon:         e.lon,
+	}
+	if e.kind != PrecisionCountry {
+		g.Place = e.display
+	}
+	return g
+}
+
+// bestMatch 在文本中查找候选名称，地点优先于地区，同类时名称越长越具体
+func bestMatch(candidates []entry, text string, minRunes int) (entry, bool) {
+	var best entry
+	bestScore := 0
+	for _, e := range candidates {
+		for _, name := range e.names {
+			if !containsName(text, name, minRunes) {
+				continue
+			}
+			score := utf8.RuneCountInString(name)
+			if e.kind == PrecisionPlace {
+				score += 1000
+			}
+			if score > bestScore {
+				best, bestScore = e, score
+			}
+		}
+	}
+	return best, bestScore > 0
+}
+
+// uniqueMatch 地点的某一段与名称完全相同、且只对应一个国家时返回该条目
+func uniqueMatch(segments []string) (entry, bool) {
+	for i := len(segments) - 1; i >= 0; i-- {
+		segment := strings.ToLower(segments[i])
+		var found []entry
+		for _, entries := range places {
+			for _, e := range entries {
+				for _, name := range e.names {
+					if name == segment {
+						found = append(found, e)
+						break
+					}
+				}
+			}
+		}
+		if len(found) == 1 {
+			return found[0], true
+		}
+	}
+	return entry{}, false
+}
+
+// containsName 文本是否包含名称：中日文按子串匹配，其他按完整单词匹配
+func containsName(text, name string, minRunes int) bool {
+	if isCJK(name) {
+		return utf8.RuneCountInString(name) >= minRunes && strings.Contains(text, name)
+	}
+	for offset := 0; ; {
+		i := strings.Index(text[offset:], name)
+		if i < 0 {
+			return false
+		}
+		start, end := offset+i, offset+i+len(name)
+		if isBoundary(text, start, -1) && isBoundary(text, end, 1) {
+			return true
+		}
+		offset = start + 1
+	}
+}
+
+// isBoundary 位置 i 的前一个（dir<0）或当前（dir>0）字符是否为单词边界
+func isBoundary(text string, i, dir int) bool {
+	var r rune
+	if dir < 0 {
+		if i == 0 {
+			return true
+		}
+		r, _ = utf8.DecodeLastRuneInString(text[:i])
+	} else {
+		if i == len(text) {
+			return true
+		}
+		r, _ = utf8.DecodeRuneInString(text[i:])
+	}
+	return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+}
+
+// isCJK 名称是否包含汉字或假名
+func isCJK(name string) bool {
+	for _, r := range name {
+		if unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana) {
+			return true
+		}
+	}
+	return false
+}
+
+// splitLocation 拆分 Credit.Location，如 "Lake Powell, Utah"
+func splitLocation(location string) []string {
+	var segments []string
+	for _, segment := range strings.Split(location, ",") {
+		if segment = strings.TrimSpace(segment); segment != "" {
+			segments = append(segments, segment)
+		}
+	}
+	return segments
+}
+
+// load 解析内置的地名表，格式错误说明地名表本身有问题，直接 panic
+func load() {
+	countries = make(map[string]entry)
+	places = make(map[string][]entry)
+
+	for n, line := range strings.Split(gazetteerData, "\n") {
+		if line = strings.TrimSpace(line); line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.Split(line, "\t")
+		if len(fields) < 4 {
+			panic("geo: invalid gazetteer line " + strconv.Itoa(n+1))
+		}
+		lat, errLat := strconv.ParseFloat(fields[2], 64)
+		lon, errLon := strconv.ParseFloat(fields[3], 64)
+		if errLat != nil || errLon != nil {
+			panic("geo: invalid coordinates on gazetteer line " + strconv.Itoa(n+1))
+		}
+
+		e := entry{country: fields[0], kind: fields[1], lat: lat, lon: lon}
+		if e.kind == PrecisionCountry {
+			countries[e.country] = e
+			continue
+		}
+		if len(fields) < 5 {
+			panic("geo: missing names on gazetteer line " + strconv.Itoa(n+1))
+		}
+		names := strings.Split(fields[4], "|")
+		e.display = names[0]
+		for _, name := range names {
+			e.names = append(e.names, strings.ToLower(name))
+		}
+		places[e.country] = append(places[e.country], e)
+	}
+}
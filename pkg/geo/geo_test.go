@@ -0,0 +1,126 @@
+package geo
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gclm/galaxy-bing-wallpapers/pkg/copyright"
+	"github.com/gclm/galaxy-bing-wallpapers/pkg/model"
+)
+
+// archiveDir 各市场的归档数据，格式与 cmd/init 导入的文件相同
+const archiveDir = "../../data"
+
+// maxDisagreement 同一张图片在各市场定位到不同国家的比例上限
+// 少数市场的描述本身就写了不同的国家或地区，如 Falkland Islands 和 イギリス；
+// 美国以外的英文市场还会把只写 Georgia 的美国地点识别为格鲁吉亚。修正解析规则前约为 3%
+const maxDisagreement = 0.02
+
+type archiveWallpaper struct {
+	Url       string `json:"url"`
+	Copyright string `json:"copyright"`
+}
+
+func loadArchive(t *testing.T) map[string][]archiveWallpaper {
+	t.Helper()
+	files, err := filepath.Glob(filepath.Join(archiveDir, "*_all.json"))
+	if err != nil || len(files) == 0 {
+		t.Skip("archive data not found")
+	}
+
+	archive := make(map[string][]archiveWallpaper)
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var doc struct {
+			Data []archiveWallpaper `json:"data"`
+		}
+		if err := json.Unmarshal(data, &doc); err != nil {
+			t.Fatalf("%s: %v", file, err)
+		}
+		mkt := strings.TrimSuffix(filepath.Base(file), "_all.json")
+		archive[mkt] = doc.Data
+	}
+	return archive
+}
+
+// TestLocateArchiveSample 解析定位 data/ 中的归档版权信息，检查国家是否一致
+func TestLocateArchiveSample(t *testing.T) {
+	archive := loadArchive(t)
+
+	located := 0
+	countries := make(map[string]map[string]string) // 图片标识 -> 市场 -> 国家代码
+	for mkt, wallpapers := range archive {
+		for _, w := range wallpapers {
+			credit := copyright.Parse(w.Copyright, mkt)
+			g := Locate(credit)
+			if g == nil {
+				continue
+			}
+			located++
+
+			if credit.CountryCode != "" && g.CountryCode != credit.CountryCode {
+				t.Errorf("%s %q: located in %s, credit says %s", mkt, w.Copyright, g.CountryCode, credit.CountryCode)
+			}
+			if g.Lat < -90 || g.Lat > 90 || g.Lon < -180 || g.Lon > 180 {
+				t.Errorf("%s %q: invalid coordinates %v,%v", mkt, w.Copyright, g.Lat, g.Lon)
+			}
+
+			if ohr := model.ParseOHR(w.Url); ohr != "" {
+				if countries[ohr] == nil {
+					countries[ohr] = make(map[string]string)
+				}
+				countries[ohr][mkt] = g.CountryCode
+			}
+		}
+	}
+	if located == 0 {
+		t.Fatal("no wallpaper located")
+	}
+
+	shared, disagree := 0, 0
+	for ohr, byMarket := range countries {
+		if len(byMarket) < 2 {
+			continue
+		}
+		shared++
+		codes := make(map[string]bool)
+		for _, code := range byMarket {
+			codes[code] = true
+		}
+		if len(codes) > 1 {
+			disagree++
+			t.Logf("%s: %v", ohr, byMarket)
+		}
+	}
+	if shared > 0 && float64(disagree)/float64(shared) > maxDisagreement {
+		t.Errorf("%d of %d images shared across markets are located in different countries", disagree, shared)
+	}
+}
+
+// TestLocateFalseCountries 以前被误认为其他国家的归档记录
+func TestLocateFalseCountries(t *testing.T) {
+	tests := []struct {
+		mkt       string
+		copyright string
+		want      string
+	}{
+		{"de-DE", "Flussseeschwalbenvater mit Küken, Nickerson Beach, Long Island, New York (© Vicki Jauron, Babylon and Beyond Photography/Getty Images)", "US"},
+		{"en-US", "Kodiak National Wildlife Refuge, Kodiak Island, Alaska (© Ian Shive/Tandem Stills + Motion)", "US"},
+		{"en-US", "Turkey tail mushroom, Brevard, North Carolina (© Bill Gozansky/Alamy)", "US"},
+		{"en-US", "Bonaventure Cemetery, Savannah, Georgia (© Kelly vanDellen/Alamy)", "US"},
+		{"it-IT", "Parco Nazionale di White Sands, Nuovo Messico (© Andrea Harrell/Tandem Stills + Motion)", "US"},
+		{"de-DE", "Wasserfall in Skaftafell, Vatnajökull-Nationalpark, Island (© Nopasorn Kowathanakul/Getty Images)", "IS"},
+	}
+	for _, tt := range tests {
+		g := Locate(copyright.Parse(tt.copyright, tt.mkt))
+		if g == nil || g.CountryCode != tt.want {
+			t.Errorf("%s %q: got %+v, want %s", tt.mkt, tt.copyright, g, tt.want)
+		}
+	}
+}
@@ -0,0 +1,195 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gclm/galaxy-bing-wallpapers/pkg/cache"
+	"github.com/gclm/galaxy-bing-wallpapers/pkg/config"
+	"github.com/gclm/galaxy-bing-wallpapers/pkg/database"
+	"github.com/gclm/galaxy-bing-wallpapers/pkg/model"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// 日历中缩略图的尺寸，必应提供的最小横版尺寸之一
+const (
+	thumbnailWidth  = "400"
+	thumbnailHeight = "240"
+)
+
+var (
+	resultCacheOnce sync.Once
+	resultCache     *cache.Cache
+)
+
+// GetCalendar 获取某市场某月每一天的壁纸，没有壁纸的日期标记为 missing
+// 支持与列表接口相同的过滤参数，mkt 默认为 zh-CN；只返回到该市场时区的今天为止
+func GetCalendar(c *gin.Context) {
+	year, month, err := calendarMonth(c.Param("year"), c.Param("month"))
+	if err != nil {
+		HandleError(c, err)
+		return
+	}
+	filter, err := buildWallpaperFilter(c)
+	if err != nil {
+		HandleError(c, err)
+		return
+	}
+	if _, ok := filter["mkt"]; !ok {
+		filter["mkt"] = "zh-CN"
+	}
+	mkt := filter["mkt"].(string)
+
+	first := time.Date(year, month, 1, 0, 0, 0, 0, time.UTC)
+	last := first.AddDate(0, 1, -1)
+	today := time.Now().In(model.MarketLocation(mkt)).Format("2006-01-02")
+	filter["datetime"] = bson.M{"$gte": first.Format("2006-01-02"), "$lte": last.Format("2006-01-02")}
+
+	respondCached(c, func(ctx context.Context) (model.ApiResponse, error) {
+		wallpapers, err := database.ListWallpapers(ctx, filter, bson.D{{Key: "datetime", Value: 1}})
+		if err != nil {
+			return model.ApiResponse{}, err
+		}
+		byDate := make(map[string]model.Wallpaper, len(wallpapers))
+		for _, w := range wallpapers {
+			byDate[w.Datetime] = w
+		}
+
+		days := make([]model.CalendarDay, 0, last.Day())
+		for d := first; !d.After(last); d = d.AddDate(0, 0, 1) {
+			date := d.Format("2006-01-02")
+			if date > today {
+				break
+			}
+			w, ok := byDate[date]
+			if !ok {
+				days = append(days, model.CalendarDay{Date: date, Missing: true})
+				continue
+			}
+			days = append(days, model.CalendarDay{
+				Date:      date,
+				ID:        w.ID,
+				Title:     w.Title,
+				Url:       w.Url,
+				Thumbnail: w.GenerateImageURL(thumbnailWidth, thumbnailHeight),
+				OHR:       w.OHR,
+			})
+		}
+
+		return model.ApiResponse{
+			Code:    http.StatusOK,
+			Message: "success",
+			Data:    days,
+			Total:   int64(len(wallpapers)),
+		}, nil
+	})
+}
+
+// GetArchive 按年、月、市场统计壁纸数量，支持与列表接口相同的过滤参数
+func GetArchive(c *gin.Context) {
+	filter, err := buildWallpaperFilter(c)
+	if err != nil {
+		HandleError(c, err)
+		return
+	}
+
+	respondCached(c, func(ctx context.Context) (model.ApiResponse, error) {
+		counts, err := database.ArchiveCounts(ctx, filter)
+		if err != nil {
+			return model.ApiResponse{}, err
+		}
+		years, total := groupArchive(counts)
+		return model.ApiResponse{
+			Code:    http.StatusOK,
+			Message: "success",
+			Data:    years,
+			Total:   total,
+		}, nil
+	})
+}
+
+// groupArchive 将按年、月倒序的统计结果组织为年、月两级，返回总数
+func groupArchive(counts []model.ArchiveCount) ([]model.ArchiveYear, int64) {
+	years := []model.ArchiveYear{}
+	var total int64
+	for _, count := range counts {
+		if len(years) == 0 || years[len(years)-1].Year != count.Year {
+			years = append(years, model.ArchiveYear{Year: count.Year})
+		}
+		year := &years[len(years)-1]
+		if len(year.Months) == 0 || year.Months[len(year.Months)-1].Month != count.Month {
+			year.Months = append(year.Months, model.ArchiveMonth{Month: count.Month, Markets: map[string]int64{}})
+		}
+		month := &year.Months[len(year.Months)-1]
+
+		month.Markets[count.Mkt] = count.Count
+		month.Count += count.Count
+		year.Count += count.Count
+		total += count.Count
+	}
+	return years, total
+}
+
+// calendarMonth 校验路径中的年和月
+func calendarMonth(yearParam, monthParam string) (int, time.Month, error) {
+	year, errYear := strconv.Atoi(yearParam)
+	month, errMonth := strconv.Atoi(monthParam)
+	if errYear != nil || errMonth != nil || year < 1 || year > 9999 || month < 1 || month > 12 {
+		return 0, 0, NewError(http.StatusBadRequest, CodeInvalidDate, yearParam+"/"+monthParam)
+	}
+	return year, time.Month(month), nil
+}
+
+// respondCached 按请求路径、查询参数和数据版本缓存响应，以缓存条目的指纹作为 ETag
+// 壁纸或抓取记录有写入（包括原地修改）时数据版本递增，旧的缓存不再命中；compute 只在缓存未命中时调用
+func respondCached(c *gin.Context, compute func(ctx context.Context) (model.ApiResponse, error)) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	version, err := database.DataVersion(ctx)
+	if err != nil {
+		HandleError(c, err)
+		return
+	}
+	results := getResultCache()
+	key := cache.Key(c.Request.URL.Path, c.Request.URL.Query().Encode(), version)
+
+	entry, ok := results.Get(key)
+	if !ok {
+		response, err := compute(ctx)
+		if err != nil {
+			HandleError(c, err)
+			return
+		}
+		if entry, err = results.Set(key, response); err != nil {
+			HandleError(c, err)
+			return
+		}
+	}
+
+	etag := `"` + entry.Fingerprint + `"`
+	c.Header("ETag", etag)
+	// 客户端每次用 ETag 向服务端确认，新写入后能立即拿到新结果
+	c.Header("Cache-Control", "private, no-cache")
+	if c.GetHeader("If-None-Match") == etag {
+		c.Status(http.StatusNotModified)
+		return
+	}
+	c.JSON(http.StatusOK, entry.Value)
+}
+
+// getResultCache 聚合接口共用的结果缓存
+func getResultCache() *cache.Cache {
+	resultCacheOnce.Do(func() {
+		ttl := 10 * time.Minute
+		if config.GlobalConfig != nil {
+			ttl = config.GlobalConfig.CacheTTL
+		}
+		resultCache = cache.New(ttl)
+	})
+	return resultCache
+}
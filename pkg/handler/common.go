@@ -1,41 +1,153 @@
 package handler
 
 import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+
 	"github.com/gin-gonic/gin"
 	"go.mongodb.org/mongo-driver/mongo"
 )
 
+// ErrorCode 机器可读的错误码，取值稳定，客户端可据此分支处理
+type ErrorCode string
+
+const (
+	CodeWallpaperNotFound  ErrorCode = "WALLPAPER_NOT_FOUND"
+	CodeInvalidMarket      ErrorCode = "INVALID_MARKET"
+	CodeInvalidDate        ErrorCode = "INVALID_DATE"
+	CodeInvalidTimezone    ErrorCode = "INVALID_TIMEZONE"
+	CodeInvalidParameter   ErrorCode = "INVALID_PARAMETER"
+	CodeUnsupportedType    ErrorCode = "UNSUPPORTED_RESPONSE_TYPE"
+	CodeImageNotAnalyzed   ErrorCode = "IMAGE_NOT_ANALYZED"
+	CodeTokenRequired      ErrorCode = "AUTH_TOKEN_REQUIRED"
+	CodeTokenInvalid       ErrorCode = "AUTH_TOKEN_INVALID"
+	CodeRouteNotFound      ErrorCode = "ROUTE_NOT_FOUND"
+	CodeDatabaseError      ErrorCode = "DATABASE_ERROR"
+	CodeServiceUnavailable ErrorCode = "SERVICE_UNAVAILABLE"
+	CodeInternalError      ErrorCode = "INTERNAL_ERROR"
+)
+
+const problemJSON = "application/problem+json"
+
 // ErrorResponse 错误响应结构
 type ErrorResponse struct {
-	Code    int    `json:"code"`    // 错误码
-	Message string `json:"message"` // 错误信息
+	Code    int       `json:"code"`             // HTTP 状态码
+	Error   ErrorCode `json:"error"`            // 错误码
+	Message string    `json:"message"`          // 错误信息，按 Accept-Language 本地化
+	Detail  string    `json:"detail,omitempty"` // 补充说明，如出错的参数
+}
+
+// ProblemDetails RFC 7807 错误响应结构，请求头 Accept 包含 application/problem+json 时返回
+type ProblemDetails struct {
+	Type     string    `json:"type"`
+	Title    string    `json:"title"`
+	Status   int       `json:"status"`
+	Detail   string    `json:"detail,omitempty"`
+	Instance string    `json:"instance,omitempty"`
+	Code     ErrorCode `json:"code"`
+}
+
+// APIError 携带 HTTP 状态码和错误码的错误
+type APIError struct {
+	Status int       // HTTP 状态码
+	Code   ErrorCode // 错误码
+	Detail string    // 补充说明，会返回给客户端
+	Err    error     // 内部错误，仅记录日志，不返回给客户端
+}
+
+func (e *APIError) Error() string {
+	if e.Err != nil {
+		return fmt.Sprintf("%s: %v", e.Code, e.Err)
+	}
+	if e.Detail != "" {
+		return fmt.Sprintf("%s: %s", e.Code, e.Detail)
+	}
+	return string(e.Code)
+}
+
+func (e *APIError) Unwrap() error {
+	return e.Err
+}
+
+// NewError 创建 API 错误
+func NewError(status int, code ErrorCode, detail string) *APIError {
+	return &APIError{Status: status, Code: code, Detail: detail}
 }
 
-// HandleError 统一错误处理
+// HandleError 统一错误处理，写入错误响应并中止后续处理
+// 非 APIError 的内部错误只记录日志，不把原始信息返回给客户端
 func HandleError(c *gin.Context, err error) {
-	switch err {
-	case mongo.ErrNoDocuments:
-		c.JSON(404, ErrorResponse{
-			Code:    404,
-			Message: "Wallpaper not found",
-		})
+	var apiErr *APIError
+	switch {
+	case errors.As(err, &apiErr):
+	case errors.Is(err, mongo.ErrNoDocuments):
+		apiErr = &APIError{Status: http.StatusNotFound, Code: CodeWallpaperNotFound}
+	case isDatabaseError(err):
+		apiErr = &APIError{Status: http.StatusInternalServerError, Code: CodeDatabaseError, Err: err}
 	default:
-		c.JSON(500, ErrorResponse{
-			Code:    500,
-			Message: err.Error(),
-		})
+		apiErr = &APIError{Status: http.StatusInternalServerError, Code: CodeInternalError, Err: err}
+	}
+
+	if apiErr.Err != nil {
+		log.Printf("%s %s: %v", c.Request.Method, c.Request.URL.Path, apiErr.Err)
 	}
+
+	writeError(c, apiErr)
+	c.Abort()
+}
+
+// NotFound 未匹配路由的处理器
+func NotFound(c *gin.Context) {
+	HandleError(c, NewError(http.StatusNotFound, CodeRouteNotFound, c.Request.URL.Path))
 }
 
+// ErrorMiddleware 将 c.Errors 中的最后一个错误转换为统一的错误响应
 func ErrorMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		c.Next()
 
-		if len(c.Errors) > 0 {
-			c.JSON(c.Writer.Status(), gin.H{
-				"error":  c.Errors.Last().Err.Error(),
-				"status": c.Writer.Status(),
-			})
+		if len(c.Errors) > 0 && !c.Writer.Written() {
+			HandleError(c, c.Errors.Last().Err)
 		}
 	}
 }
+
+// writeError 按客户端协商结果输出错误响应
+func writeError(c *gin.Context, apiErr *APIError) {
+	message := localizedMessage(apiErr.Code, c.GetHeader("Accept-Language"))
+
+	if strings.Contains(c.GetHeader("Accept"), problemJSON) {
+		c.Header("Content-Type", problemJSON)
+		c.JSON(apiErr.Status, ProblemDetails{
+			Type:     "urn:galaxy-bing-wallpapers:error:" + strings.ToLower(string(apiErr.Code)),
+			Title:    message,
+			Status:   apiErr.Status,
+			Detail:   apiErr.Detail,
+			Instance: c.Request.URL.Path,
+			Code:     apiErr.Code,
+		})
+		return
+	}
+
+	c.JSON(apiErr.Status, ErrorResponse{
+		Code:    apiErr.Status,
+		Error:   apiErr.Code,
+		Message: message,
+		Detail:  apiErr.Detail,
+	})
+}
+
+// isDatabaseError 判断是否为 MongoDB 返回的错误
+func isDatabaseError(err error) bool {
+	var (
+		serverErr  mongo.ServerError
+		commandErr mongo.CommandError
+	)
+	return errors.As(err, &serverErr) ||
+		errors.As(err, &commandErr) ||
+		mongo.IsNetworkError(err) ||
+		mongo.IsTimeout(err)
+}
@@ -0,0 +1,135 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gclm/galaxy-bing-wallpapers/pkg/database"
+	"github.com/gclm/galaxy-bing-wallpapers/pkg/model"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	// defaultGeoLimit geo 接口默认返回的要素数量
+	defaultGeoLimit = 1000
+	// maxGeoLimit limit 参数的上限
+	maxGeoLimit = 5000
+)
+
+// GetWallpaperGeo 以 GeoJSON FeatureCollection 返回带坐标的壁纸
+// 支持与列表接口相同的过滤参数，以及 bbox（minLon,minLat,maxLon,maxLat）范围过滤
+func GetWallpaperGeo(c *gin.Context) {
+	filter, err := buildWallpaperFilter(c)
+	if err != nil {
+		HandleError(c, err)
+		return
+	}
+	filter["geo"] = bson.M{"$exists": true}
+
+	if value := c.Query("bbox"); value != "" {
+		if err := applyBBox(filter, value); err != nil {
+			HandleError(c, err)
+			return
+		}
+	}
+
+	limit := defaultGeoLimit
+	if value := c.Query("limit"); value != "" {
+		limit, err = strconv.Atoi(value)
+		if err != nil || limit < 1 || limit > maxGeoLimit {
+			HandleError(c, NewError(http.StatusBadRequest, CodeInvalidParameter, "limit must be between 1 and "+strconv.Itoa(maxGeoLimit)))
+			return
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	cursor, err := database.GetCollection("wallpapers").Find(ctx, filter, options.Find().
+		SetSort(bson.D{{Key: "datetime", Value: -1}, {Key: "id", Value: -1}}).
+		SetLimit(int64(limit)))
+	if err != nil {
+		HandleError(c, err)
+		return
+	}
+	defer cursor.Close(ctx)
+
+	var wallpapers []model.Wallpaper
+	if err := cursor.All(ctx, &wallpapers); err != nil {
+		HandleError(c, err)
+		return
+	}
+
+	collection := model.FeatureCollection{Type: "FeatureCollection", Features: make([]model.Feature, 0, len(wallpapers))}
+	for _, wallpaper := range wallpapers {
+		collection.Features = append(collection.Features, geoFeature(wallpaper))
+	}
+
+	c.Header("Content-Type", "application/geo+json; charset=utf-8")
+	c.JSON(http.StatusOK, collection)
+}
+
+// geoFeature 将壁纸转换为 GeoJSON 要素
+func geoFeature(wallpaper model.Wallpaper) model.Feature {
+	properties := map[string]interface{}{
+		"title":        wallpaper.Title,
+		"datetime":     wallpaper.Datetime,
+		"mkt":          wallpaper.Mkt,
+		"url":          wallpaper.Url,
+		"copyright":    wallpaper.Copyright,
+		"country_code": wallpaper.Geo.CountryCode,
+		"precision":    wallpaper.Geo.Precision,
+	}
+	if wallpaper.Geo.Place != "" {
+		properties["place"] = wallpaper.Geo.Place
+	}
+	return model.Feature{
+		Type: "Feature",
+		ID:   wallpaper.ID,
+		Geometry: model.Point{
+			Type:        "Point",
+			Coordinates: [2]float64{wallpaper.Geo.Lon, wallpaper.Geo.Lat},
+		},
+		Properties: properties,
+	}
+}
+
+// applyBBox 解析 bbox 参数并加入过滤条件
+// 顺序与 GeoJSON 相同：minLon,minLat,maxLon,maxLat；minLon 大于 maxLon 时表示跨越 180 度经线
+func applyBBox(filter bson.M, value string) error {
+	invalid := NewError(http.StatusBadRequest, CodeInvalidParameter, "bbox must be minLon,minLat,maxLon,maxLat")
+
+	parts := strings.Split(value, ",")
+	if len(parts) != 4 {
+		return invalid
+	}
+	var box [4]float64
+	for i, part := range parts {
+		v, err := strconv.ParseFloat(strings.TrimSpace(part), 64)
+		if err != nil {
+			return invalid
+		}
+		box[i] = v
+	}
+	minLon, minLat, maxLon, maxLat := box[0], box[1], box[2], box[3]
+	if minLon < -180 || maxLon > 180 || minLon > 180 || maxLon < -180 ||
+		minLat < -90 || maxLat > 90 || minLat > maxLat {
+		return invalid
+	}
+
+	filter["geo.lat"] = bson.M{"$gte": minLat, "$lte": maxLat}
+	if minLon <= maxLon {
+		filter["geo.lon"] = bson.M{"$gte": minLon, "$lte": maxLon}
+	} else {
+		filter["$or"] = bson.A{
+			bson.M{"geo.lon": bson.M{"$gte": minLon}},
+			bson.M{"geo.lon": bson.M{"$lte": maxLon}},
+		}
+	}
+	return nil
+}
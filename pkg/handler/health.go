@@ -1,22 +1,194 @@
 package handler
 
 import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/gclm/galaxy-bing-wallpapers/pkg/config"
 	"github.com/gclm/galaxy-bing-wallpapers/pkg/database"
+	"github.com/gclm/galaxy-bing-wallpapers/pkg/model"
+	"github.com/gclm/galaxy-bing-wallpapers/pkg/version"
 	"github.com/gin-gonic/gin"
 )
 
+const (
+	statusOK   = "ok"
+	statusFail = "fail"
+)
+
+var startTime = time.Now()
+
+// CheckResult 单项检查结果
+type CheckResult struct {
+	Name      string  `json:"name"`              // 检查项名称
+	Status    string  `json:"status"`            // ok / fail
+	LatencyMs float64 `json:"latency_ms"`        // 耗时（毫秒）
+	Message   string  `json:"message,omitempty"` // 失败原因或附加信息
+}
+
+// LivenessResponse 存活检查响应结构
+type LivenessResponse struct {
+	Status string       `json:"status"`
+	Uptime string       `json:"uptime"`
+	Build  version.Info `json:"build"`
+}
+
+// ReadinessResponse 就绪检查响应结构
+type ReadinessResponse struct {
+	Status string        `json:"status"`
+	Checks []CheckResult `json:"checks"`
+	Build  version.Info  `json:"build"`
+}
+
+// HealthCheck 兼容旧版的健康检查，仅检查数据库连接
 func HealthCheck(c *gin.Context) {
 	// 检查数据库连接
-	if err := database.Client.Ping(c, nil); err != nil {
-		c.JSON(500, ErrorResponse{
-			Code:    500,
-			Message: "Database connection error",
+	if err := database.Ping(c); err != nil {
+		HandleError(c, &APIError{
+			Status: http.StatusServiceUnavailable,
+			Code:   CodeServiceUnavailable,
+			Detail: "database connection error",
+			Err:    err,
 		})
 		return
 	}
 
 	c.JSON(200, gin.H{
 		"status":  "ok",
-		"version": "1.0.0",
+		"version": version.Version,
+	})
+}
+
+// Liveness 存活检查，只要进程能响应即返回 200
+func Liveness(c *gin.Context) {
+	c.JSON(http.StatusOK, LivenessResponse{
+		Status: statusOK,
+		Uptime: time.Since(startTime).Round(time.Second).String(),
+		Build:  version.Get(),
+	})
+}
+
+// Readiness 就绪检查，检查数据库连接、各市场最近一次成功抓取是否在阈值内，缩略图目录是否可写以及快照目录是否可读
+func Readiness(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
+	defer cancel()
+
+	checks := []CheckResult{runCheck("mongodb", func() error {
+		return database.Ping(ctx)
+	})}
+	checks = append(checks, checkFetchFreshness(ctx)...)
+	checks = append(checks, checkDirectories()...)
+
+	status, code := statusOK, http.StatusOK
+	for _, check := range checks {
+		if check.Status != statusOK {
+			status, code = statusFail, http.StatusServiceUnavailable
+			break
+		}
+	}
+
+	c.JSON(code, ReadinessResponse{
+		Status: status,
+		Checks: checks,
+		Build:  version.Get(),
+	})
+}
+
+// runCheck 执行单项检查并记录耗时
+func runCheck(name string, fn func() error) CheckResult {
+	start := time.Now()
+	err := fn()
+	result := CheckResult{
+		Name:      name,
+		Status:    statusOK,
+		LatencyMs: float64(time.Since(start).Microseconds()) / 1000,
+	}
+	if err != nil {
+		result.Status = statusFail
+		result.Message = err.Error()
+	}
+	return result
+}
+
+// checkFetchFreshness 检查各市场最近一次成功抓取的时间
+// 没有抓取记录的市场（如抓取日志上线前导入的数据）退回到最新壁纸的日期
+func checkFetchFreshness(ctx context.Context) []CheckResult {
+	maxAge := config.GlobalConfig.ReadyFetchMaxAge
+
+	var (
+		fetches map[string]time.Time
+		latest  map[string]string
+	)
+	query := runCheck("fetch_logs", func() error {
+		var err error
+		if fetches, err = database.LastSuccessfulFetches(ctx); err != nil {
+			return err
+		}
+		latest, err = database.LatestWallpaperDates(ctx)
+		return err
 	})
+	if query.Status != statusOK {
+		return []CheckResult{query}
+	}
+
+	results := make([]CheckResult, 0, len(model.Markets))
+	for _, mkt := range model.Markets {
+		results = append(results, runCheck("fetch:"+mkt, func() error {
+			last, ok := fetches[mkt]
+			if !ok {
+				date, found := latest[mkt]
+				if !found {
+					return fmt.Errorf("no successful fetch recorded")
+				}
+				// 壁纸日期按当天结束计算
+				day, err := time.Parse("2006-01-02", date)
+				if err != nil {
+					return fmt.Errorf("invalid latest wallpaper date %q", date)
+				}
+				last = day.Add(24 * time.Hour)
+			}
+
+			if age := time.Since(last); age > maxAge {
+				return fmt.Errorf("last successful fetch %s ago exceeds %s", age.Round(time.Minute), maxAge)
+			}
+			return nil
+		}))
+	}
+	return results
+}
+
+// checkDirectories 检查缩略图缓存目录是否存在且可写，以及快照目录（IMAGE_MIRROR_DIR，未配置时跳过）是否可读
+// 只检查状态不创建目录：缩略图目录在服务启动时创建，快照目录由 cmd/backup 写入，服务只读取其中的图片
+func checkDirectories() []CheckResult {
+	results := []CheckResult{runCheck("thumbnail_dir", func() error {
+		dir := config.GlobalConfig.ThumbnailDir
+		if info, err := os.Stat(dir); err != nil {
+			return err
+		} else if !info.IsDir() {
+			return fmt.Errorf("%s is not a directory", dir)
+		}
+		return checkWritable(dir)
+	})}
+	if dir := config.GlobalConfig.ImageMirrorDir; dir != "" {
+		results = append(results, runCheck("snapshot_dir", func() error {
+			if _, err := os.ReadDir(dir); err != nil {
+				return fmt.Errorf("%s is not readable: %v", dir, err)
+			}
+			return nil
+		}))
+	}
+	return results
+}
+
+// checkWritable 在目录中创建并删除一个临时文件
+func checkWritable(dir string) error {
+	file, err := os.CreateTemp(dir, ".readyz-*")
+	if err != nil {
+		return fmt.Errorf("%s is not writable: %v", dir, err)
+	}
+	file.Close()
+	return os.Remove(file.Name())
 }
@@ -0,0 +1,82 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"regexp"
+	"sort"
+	"time"
+
+	"github.com/gclm/galaxy-bing-wallpapers/pkg/database"
+	"github.com/gclm/galaxy-bing-wallpapers/pkg/model"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// ohrParamPattern 图片标识的格式，如 BlueBelize
+var ohrParamPattern = regexp.MustCompile(`^[A-Za-z0-9]+$`)
+
+// GetImageVariants 获取同一张图片在各市场的版本，便于对照各语言的标题和版权信息
+func GetImageVariants(c *gin.Context) {
+	ohr := c.Param("ohr")
+	if !ohrParamPattern.MatchString(ohr) {
+		HandleError(c, NewError(http.StatusBadRequest, CodeInvalidParameter, "ohr: "+ohr))
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	sortByDate := bson.D{{Key: "datetime", Value: 1}, {Key: "id", Value: 1}}
+	wallpapers, err := database.ListWallpapers(ctx, bson.M{"ohr": ohr}, sortByDate)
+	if err == nil && len(wallpapers) == 0 {
+		// 标识区分大小写，精确匹配不到时再忽略大小写查一次
+		wallpapers, err = database.ListWallpapers(ctx, bson.M{"ohr": bson.M{"$regex": "^" + ohr + "$", "$options": "i"}}, sortByDate)
+	}
+	if err != nil {
+		HandleError(c, err)
+		return
+	}
+	if len(wallpapers) == 0 {
+		HandleError(c, mongo.ErrNoDocuments)
+		return
+	}
+
+	c.JSON(http.StatusOK, model.ApiResponse{
+		Code:    http.StatusOK,
+		Message: "success",
+		Data:    groupImageVariants(wallpapers),
+		Total:   int64(len(wallpapers)),
+	})
+}
+
+// groupImageVariants 汇总同一张图片的各市场版本，wallpapers 已按日期排序
+func groupImageVariants(wallpapers []model.Wallpaper) model.ImageGroup {
+	order := make(map[string]int, len(model.Markets))
+	for i, mkt := range model.Markets {
+		order[mkt] = i
+	}
+	// 同一天按市场列表的顺序
+	sort.SliceStable(wallpapers, func(i, j int) bool {
+		if wallpapers[i].Datetime != wallpapers[j].Datetime {
+			return wallpapers[i].Datetime < wallpapers[j].Datetime
+		}
+		return order[wallpapers[i].Mkt] < order[wallpapers[j].Mkt]
+	})
+
+	group := model.ImageGroup{
+		OHR:       wallpapers[0].OHR,
+		FirstSeen: wallpapers[0].Datetime,
+		LastSeen:  wallpapers[len(wallpapers)-1].Datetime,
+		Variants:  wallpapers,
+	}
+	seen := make(map[string]bool)
+	for _, wallpaper := range wallpapers {
+		if !seen[wallpaper.Mkt] {
+			seen[wallpaper.Mkt] = true
+			group.Markets = append(group.Markets, wallpaper.Mkt)
+		}
+	}
+	return group
+}
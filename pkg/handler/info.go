@@ -3,12 +3,15 @@ package handler
 import (
 	"net/http"
 
+	"github.com/gclm/galaxy-bing-wallpapers/pkg/version"
 	"github.com/gin-gonic/gin"
 )
 
 type InfoResponse struct {
 	Name        string `json:"name"`
 	Version     string `json:"version"`
+	Commit      string `json:"commit"`
+	BuildTime   string `json:"build_time"`
 	Author      string `json:"author"`
 	Description string `json:"description"`
 	Repository  string `json:"repository"`
@@ -17,7 +20,9 @@ type InfoResponse struct {
 func GetInfo(c *gin.Context) {
 	c.JSON(http.StatusOK, InfoResponse{
 		Name:        "galaxy-bing-wallpapers",
-		Version:     "1.0.0",
+		Version:     version.Version,
+		Commit:      version.Commit,
+		BuildTime:   version.BuildTime,
 		Author:      "gclm",
 		Description: "Bing wallpaper API service",
 		Repository:  "https://github.com/gclm/galaxy-bing-wallpapers",
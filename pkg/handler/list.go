@@ -3,9 +3,13 @@ package handler
 import (
 	"context"
 	"net/http"
+	"regexp"
 	"strconv"
+	"strings"
 
+	"github.com/gclm/galaxy-bing-wallpapers/pkg/copyright"
 	"github.com/gclm/galaxy-bing-wallpapers/pkg/database"
+	"github.com/gclm/galaxy-bing-wallpapers/pkg/imaging"
 	"github.com/gclm/galaxy-bing-wallpapers/pkg/model"
 	"github.com/gin-gonic/gin"
 	"go.mongodb.org/mongo-driver/bson"
@@ -13,6 +17,12 @@ import (
 	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
+// 按颜色过滤时的默认和最大容差
+const (
+	defaultColorTolerance = 20
+	maxColorTolerance     = 100
+)
+
 func GetAllWallpapers(c *gin.Context) {
 	collection := database.GetCollection("wallpapers")
 	ctx := context.Background()
@@ -26,12 +36,14 @@ func GetAllWallpapers(c *gin.Context) {
 		SetSort(bson.D{{Key: "startdate", Value: -1}})
 
 	cursor, err := collection.Find(ctx, bson.M{}, opts)
-	if handleMongoError(c, err) {
+	if err != nil {
+		HandleError(c, err)
 		return
 	}
 
 	var results []model.Wallpaper
-	if err = cursor.All(ctx, &results); handleMongoError(c, err) {
+	if err = cursor.All(ctx, &results); err != nil {
+		HandleError(c, err)
 		return
 	}
 
@@ -50,7 +62,8 @@ func GetTotalCount(c *gin.Context) {
 	ctx := context.Background()
 
 	count, err := collection.CountDocuments(ctx, bson.M{})
-	if handleMongoError(c, err) {
+	if err != nil {
+		HandleError(c, err)
 		return
 	}
 
@@ -66,24 +79,33 @@ func getTotalCount(collection *mongo.Collection, ctx context.Context) int64 {
 func GetWallpaperList(c *gin.Context) {
 	page := c.DefaultQuery("page", "1")
 	pageSize := c.DefaultQuery("pageSize", "20")
-	mkt := c.DefaultQuery("mkt", "")
 
 	// 转换为整数
 	skip, limit := getPagination(page, pageSize)
 
 	// 构建查询条件
-	filter := bson.M{}
-	if mkt != "" {
-		filter["mkt"] = mkt
+	filter, err := buildWallpaperFilter(c)
+	if err != nil {
+		HandleError(c, err)
+		return
 	}
 
 	// 获取总数
-	total, wallpapers := getWallpapers(c, filter, skip, limit)
+	total, wallpapers, err := getWallpapers(filter, skip, limit)
+	if err != nil {
+		HandleError(c, err)
+		return
+	}
+
+	items := make([]model.WallpaperItem, len(wallpapers))
+	for i, wallpaper := range wallpapers {
+		items[i] = model.WallpaperItem{Wallpaper: wallpaper, Thumbnails: thumbnailURLs(wallpaper)}
+	}
 
 	c.JSON(http.StatusOK, model.ApiResponse{
 		Code:    http.StatusOK,
 		Message: "success",
-		Data:    wallpapers,
+		Data:    items,
 		Total:   total,
 	})
 }
@@ -91,10 +113,20 @@ func GetWallpaperList(c *gin.Context) {
 // GetWallpaperByDate 获取指定日期的壁纸
 func GetWallpaperByDate(c *gin.Context) {
 	date := c.Param("date") // 格式：2024-02-19
-	mkt := c.Query("mkt")   // 可选参数
-	width := c.DefaultQuery("w", "1920")
-	height := c.DefaultQuery("h", "1080")
-	responseType := c.DefaultQuery("type", "image")
+	if _, err := parseDate(date); err != nil {
+		HandleError(c, err)
+		return
+	}
+	mkt, err := marketQuery(c, "") // 可选参数
+	if err != nil {
+		HandleError(c, err)
+		return
+	}
+	responseType, err := responseTypeQuery(c, responseTypeImage)
+	if err != nil {
+		HandleError(c, err)
+		return
+	}
 
 	collection := database.GetCollection("wallpapers")
 	ctx := context.Background()
@@ -107,31 +139,114 @@ func GetWallpaperByDate(c *gin.Context) {
 
 	// 查询壁纸
 	var wallpaper model.Wallpaper
-	err := collection.FindOne(ctx, filter).Decode(&wallpaper)
-	if err != nil {
+	if err := collection.FindOne(ctx, filter).Decode(&wallpaper); err != nil {
 		HandleError(c, err)
 		return
 	}
 
-	// 生成图片URL
-	imageURL := wallpaper.GenerateImageURL(width, height)
-
 	// 根据响应类型返回数据
-	switch responseType {
-	case "image":
-		c.Redirect(http.StatusFound, imageURL)
-	case "json":
-		c.JSON(http.StatusOK, model.ImageResponse{
-			Url:      imageURL,
-			Title:    wallpaper.Title,
-			Datetime: wallpaper.Datetime,
-		})
-	default:
-		c.JSON(http.StatusBadRequest, ErrorResponse{
-			Code:    http.StatusBadRequest,
-			Message: "Unsupported response type. Use 'image' or 'json'",
-		})
+	respondWallpaper(c, wallpaper, responseType)
+}
+
+// buildWallpaperFilter 根据查询参数构建壁纸过滤条件
+// 支持 mkt（市场代码）、from/to（日期范围，YYYY-MM-DD，包含边界），
+// 以及按版权署名过滤的 photographer、agency、country 和按调色板过滤的 color、tolerance
+func buildWallpaperFilter(c *gin.Context) (bson.M, error) {
+	filter := bson.M{}
+
+	mkt, err := marketQuery(c, "")
+	if err != nil {
+		return nil, err
+	}
+	if mkt != "" {
+		filter["mkt"] = mkt
+	}
+
+	dateRange := bson.M{}
+	if from := c.Query("from"); from != "" {
+		if _, err := parseDate(from); err != nil {
+			return nil, err
+		}
+		dateRange["$gte"] = from
+	}
+	if to := c.Query("to"); to != "" {
+		if _, err := parseDate(to); err != nil {
+			return nil, err
+		}
+		dateRange["$lte"] = to
+	}
+	if len(dateRange) > 0 {
+		filter["datetime"] = dateRange
+	}
+
+	// 摄影师不区分大小写完整匹配
+	if photographer := strings.TrimSpace(c.Query("photographer")); photographer != "" {
+		filter["credit.photographer"] = bson.M{"$regex": "^" + regexp.QuoteMeta(photographer) + "$", "$options": "i"}
+	}
+	// 图片库统一为规范名称，如 getty、Getty Images Plus 都匹配 Getty Images
+	if agency := strings.TrimSpace(c.Query("agency")); agency != "" {
+		if canonical, ok := copyright.CanonicalAgency(agency); ok {
+			filter["credit.agency"] = canonical
+		} else {
+			filter["credit.agency"] = bson.M{"$regex": "^" + regexp.QuoteMeta(agency) + "$", "$options": "i"}
+		}
+	}
+	// 国家可以是 ISO 3166-1 代码或任一市场语言的名称
+	if country := strings.TrimSpace(c.Query("country")); country != "" {
+		code, err := countryQuery(country)
+		if err != nil {
+			return nil, err
+		}
+		filter["credit.country_code"] = code
+	}
+	// 调色板中有与 color 相近的颜色
+	if value := strings.TrimSpace(c.Query("color")); value != "" {
+		palette, err := colorQuery(value, c.Query("tolerance"))
+		if err != nil {
+			return nil, err
+		}
+		filter["palette"] = palette
+	}
+
+	return filter, nil
+}
+
+// colorQuery 构建按颜色匹配调色板的条件
+// tolerance 为 Lab 空间中每个分量允许的差值（默认 20），近似 CIE76 色差
+func colorQuery(value, toleranceParam string) (bson.M, error) {
+	rgb, ok := imaging.ParseHex(value)
+	if !ok {
+		return nil, NewError(http.StatusBadRequest, CodeInvalidParameter, "color: "+value)
+	}
+	tolerance := float64(defaultColorTolerance)
+	if toleranceParam != "" {
+		t, err := strconv.ParseFloat(toleranceParam, 64)
+		if err != nil || t <= 0 || t > maxColorTolerance {
+			return nil, NewError(http.StatusBadRequest, CodeInvalidParameter, "tolerance must be between 0 and "+strconv.Itoa(maxColorTolerance))
+		}
+		tolerance = t
+	}
+
+	lab := imaging.ToLab(rgb)
+	between := func(v float64) bson.M {
+		return bson.M{"$gte": v - tolerance, "$lte": v + tolerance}
+	}
+	return bson.M{"$elemMatch": bson.M{
+		"l": between(lab.L),
+		"a": between(lab.A),
+		"b": between(lab.B),
+	}}, nil
+}
+
+// countryQuery 将 country 参数转换为 ISO 3166-1 代码
+func countryQuery(country string) (string, error) {
+	if code, ok := copyright.CountryCode(country); ok {
+		return code, nil
+	}
+	if code, ok := copyright.LookupCountry(country); ok {
+		return code, nil
 	}
+	return "", NewError(http.StatusBadRequest, CodeInvalidParameter, "country: "+country)
 }
 
 // 辅助函数：获取分页参数
@@ -148,44 +263,32 @@ func getPagination(page, pageSize string) (int64, int64) {
 }
 
 // 辅助函数：获取壁纸列表
-func getWallpapers(c *gin.Context, filter bson.M, skip, limit int64) (int64, []model.Wallpaper) {
+func getWallpapers(filter bson.M, skip, limit int64) (int64, []model.Wallpaper, error) {
 	collection := database.GetCollection("wallpapers")
 	ctx := context.Background()
 
 	// 获取总数
 	total, err := collection.CountDocuments(ctx, filter)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, ErrorResponse{
-			Code:    http.StatusInternalServerError,
-			Message: "Failed to get total count",
-		})
-		return 0, nil
+		return 0, nil, err
 	}
 
 	// 查询数据
 	opts := options.Find().
 		SetSkip(skip).
 		SetLimit(limit).
-		SetSort(bson.D{{Key: "datetime", Value: -1}})
+		SetSort(bson.D{{Key: "datetime", Value: -1}, {Key: "id", Value: -1}})
 
 	cursor, err := collection.Find(ctx, filter, opts)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, ErrorResponse{
-			Code:    http.StatusInternalServerError,
-			Message: "Failed to query wallpapers",
-		})
-		return 0, nil
+		return 0, nil, err
 	}
 	defer cursor.Close(ctx)
 
 	var wallpapers []model.Wallpaper
 	if err = cursor.All(ctx, &wallpapers); err != nil {
-		c.JSON(http.StatusInternalServerError, ErrorResponse{
-			Code:    http.StatusInternalServerError,
-			Message: "Failed to decode wallpapers",
-		})
-		return 0, nil
+		return 0, nil, err
 	}
 
-	return total, wallpapers
+	return total, wallpapers, nil
 }
@@ -0,0 +1,19 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/gclm/galaxy-bing-wallpapers/pkg/model"
+	"github.com/gin-gonic/gin"
+)
+
+// GetMarkets 获取支持的市场列表
+func GetMarkets(c *gin.Context) {
+	markets := model.MarketInfos()
+	c.JSON(http.StatusOK, model.ApiResponse{
+		Code:    http.StatusOK,
+		Message: "success",
+		Data:    markets,
+		Total:   int64(len(markets)),
+	})
+}
@@ -0,0 +1,173 @@
+package handler
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// defaultLanguage 未匹配到支持语言时使用的语言
+const defaultLanguage = "en"
+
+// errorMessages 错误信息，按错误码和语言（与支持市场的语言一致）索引
+var errorMessages = map[ErrorCode]map[string]string{
+	CodeWallpaperNotFound: {
+		"en": "Wallpaper not found",
+		"zh": "未找到壁纸",
+		"de": "Hintergrundbild nicht gefunden",
+		"fr": "Fond d'écran introuvable",
+		"it": "Sfondo non trovato",
+		"ja": "壁紙が見つかりません",
+	},
+	CodeInvalidMarket: {
+		"en": "Unsupported market code",
+		"zh": "不支持的市场代码",
+		"de": "Nicht unterstützter Marktcode",
+		"fr": "Code de marché non pris en charge",
+		"it": "Codice di mercato non supportato",
+		"ja": "サポートされていないマーケットコードです",
+	},
+	CodeInvalidDate: {
+		"en": "Invalid date, expected YYYY-MM-DD",
+		"zh": "日期格式无效，应为 YYYY-MM-DD",
+		"de": "Ungültiges Datum, erwartet YYYY-MM-DD",
+		"fr": "Date invalide, format attendu AAAA-MM-JJ",
+		"it": "Data non valida, formato previsto AAAA-MM-GG",
+		"ja": "日付が無効です（YYYY-MM-DD 形式で指定してください）",
+	},
+	CodeInvalidTimezone: {
+		"en": "Invalid timezone, expected an IANA name such as Asia/Shanghai",
+		"zh": "时区无效，应为 IANA 时区名称，如 Asia/Shanghai",
+		"de": "Ungültige Zeitzone, erwartet ein IANA-Name wie Europe/Berlin",
+		"fr": "Fuseau horaire invalide, nom IANA attendu comme Europe/Paris",
+		"it": "Fuso orario non valido, previsto un nome IANA come Europe/Rome",
+		"ja": "タイムゾーンが無効です（Asia/Tokyo のような IANA 名を指定してください）",
+	},
+	CodeInvalidParameter: {
+		"en": "Invalid request parameter",
+		"zh": "请求参数无效",
+		"de": "Ungültiger Anfrageparameter",
+		"fr": "Paramètre de requête invalide",
+		"it": "Parametro della richiesta non valido",
+		"ja": "リクエストパラメータが無効です",
+	},
+	CodeUnsupportedType: {
+		"en": "Unsupported response type. Use 'image' or 'json'",
+		"zh": "不支持的返回类型，请使用 image 或 json",
+		"de": "Nicht unterstützter Antworttyp. Verwenden Sie 'image' oder 'json'",
+		"fr": "Type de réponse non pris en charge. Utilisez 'image' ou 'json'",
+		"it": "Tipo di risposta non supportato. Usa 'image' o 'json'",
+		"ja": "サポートされていないレスポンス形式です。'image' または 'json' を指定してください",
+	},
+	CodeImageNotAnalyzed: {
+		"en": "Image features have not been computed for this wallpaper yet",
+		"zh": "该壁纸的图片特征尚未计算",
+		"de": "Die Bildmerkmale dieses Hintergrundbilds wurden noch nicht berechnet",
+		"fr": "Les caractéristiques de l'image de ce fond d'écran n'ont pas encore été calculées",
+		"it": "Le caratteristiche dell'immagine di questo sfondo non sono ancora state calcolate",
+		"ja": "この壁紙の画像特徴はまだ計算されていません",
+	},
+	CodeTokenRequired: {
+		"en": "Authorization token is required",
+		"zh": "缺少访问令牌",
+		"de": "Autorisierungstoken erforderlich",
+		"fr": "Jeton d'autorisation requis",
+		"it": "Token di autorizzazione richiesto",
+		"ja": "認証トークンが必要です",
+	},
+	CodeTokenInvalid: {
+		"en": "Invalid authorization token",
+		"zh": "访问令牌无效",
+		"de": "Ungültiges Autorisierungstoken",
+		"fr": "Jeton d'autorisation invalide",
+		"it": "Token di autorizzazione non valido",
+		"ja": "認証トークンが無効です",
+	},
+	CodeRouteNotFound: {
+		"en": "Route not found",
+		"zh": "接口不存在",
+		"de": "Route nicht gefunden",
+		"fr": "Route introuvable",
+		"it": "Percorso non trovato",
+		"ja": "エンドポイントが見つかりません",
+	},
+	CodeDatabaseError: {
+		"en": "Database error",
+		"zh": "数据库错误",
+		"de": "Datenbankfehler",
+		"fr": "Erreur de base de données",
+		"it": "Errore del database",
+		"ja": "データベースエラー",
+	},
+	CodeServiceUnavailable: {
+		"en": "Service unavailable",
+		"zh": "服务不可用",
+		"de": "Dienst nicht verfügbar",
+		"fr": "Service indisponible",
+		"it": "Servizio non disponibile",
+		"ja": "サービスを利用できません",
+	},
+	CodeInternalError: {
+		"en": "Internal server error",
+		"zh": "服务器内部错误",
+		"de": "Interner Serverfehler",
+		"fr": "Erreur interne du serveur",
+		"it": "Errore interno del server",
+		"ja": "サーバー内部エラー",
+	},
+}
+
+// localizedMessage 根据 Accept-Language 获取错误信息
+func localizedMessage(code ErrorCode, acceptLanguage string) string {
+	messages, ok := errorMessages[code]
+	if !ok {
+		return string(code)
+	}
+
+	for _, lang := range parseAcceptLanguage(acceptLanguage) {
+		if message, ok := messages[lang]; ok {
+			return message
+		}
+	}
+	return messages[defaultLanguage]
+}
+
+// parseAcceptLanguage 解析 Accept-Language，按权重从高到低返回主语言标签
+// 如 "zh-CN,zh;q=0.9,en;q=0.8" 返回 [zh zh en]
+func parseAcceptLanguage(header string) []string {
+	type weighted struct {
+		lang string
+		q    float64
+	}
+
+	var langs []weighted
+	for _, part := range strings.Split(header, ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		if tag == "" || tag == "*" {
+			continue
+		}
+
+		q := 1.0
+		if value, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			if parsed, err := strconv.ParseFloat(value, 64); err == nil {
+				q = parsed
+			}
+		}
+		if q <= 0 {
+			continue
+		}
+
+		primary, _, _ := strings.Cut(tag, "-")
+		langs = append(langs, weighted{lang: strings.ToLower(primary), q: q})
+	}
+
+	sort.SliceStable(langs, func(i, j int) bool {
+		return langs[i].q > langs[j].q
+	})
+
+	result := make([]string, len(langs))
+	for i, l := range langs {
+		result[i] = l.lang
+	}
+	return result
+}
@@ -0,0 +1,104 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"time"
+
+	"github.com/gclm/galaxy-bing-wallpapers/pkg/database"
+	"github.com/gclm/galaxy-bing-wallpapers/pkg/model"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// firstWallpaperYear 必应每日壁纸最早的年份，历年同日从这一年开始查询
+const firstWallpaperYear = 2009
+
+// GetOnThisDay 获取往年同一天的壁纸
+// date 为 MM-DD，默认为市场时区的今天；type=json（默认）按年份升序返回全部，
+// type=image 随机重定向到其中一张
+func GetOnThisDay(c *gin.Context) {
+	mkt, err := marketQuery(c, "zh-CN")
+	if err != nil {
+		HandleError(c, err)
+		return
+	}
+	responseType, err := responseTypeQuery(c, responseTypeJSON)
+	if err != nil {
+		HandleError(c, err)
+		return
+	}
+	loc, err := locationQuery(c, mkt)
+	if err != nil {
+		HandleError(c, err)
+		return
+	}
+
+	now := time.Now().In(loc)
+	month, day := now.Month(), now.Day()
+	if value := c.Query("date"); value != "" {
+		// 用闰年解析，接受 02-29
+		date, err := time.Parse("2006-01-02", "2000-"+value)
+		if err != nil {
+			HandleError(c, NewError(http.StatusBadRequest, CodeInvalidDate, "date must be MM-DD: "+value))
+			return
+		}
+		month, day = date.Month(), date.Day()
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	// 逐年列出日期，按 datetime + mkt 唯一索引精确查找
+	filter := bson.M{
+		"datetime": bson.M{"$in": pastDates(month, day, now.Year())},
+		"mkt":      mkt,
+	}
+	wallpapers, err := database.ListWallpapers(ctx, filter, bson.D{{Key: "datetime", Value: 1}})
+	if err != nil {
+		HandleError(c, err)
+		return
+	}
+	if len(wallpapers) == 0 {
+		HandleError(c, mongo.ErrNoDocuments)
+		return
+	}
+
+	if responseType == responseTypeImage {
+		c.Header("Cache-Control", "no-store")
+		respondWallpaper(c, wallpapers[rand.Intn(len(wallpapers))], responseType)
+		return
+	}
+
+	// 结果到该时区的午夜前不变
+	c.Header("Cache-Control", fmt.Sprintf("public, max-age=%d", int(untilMidnight(now).Seconds())))
+	c.Writer.Header().Add("Vary", timezoneHeader)
+
+	images := make([]model.ImageResponse, len(wallpapers))
+	for i, wallpaper := range wallpapers {
+		images[i] = imageResponse(c, wallpaper)
+	}
+	c.JSON(http.StatusOK, model.ApiResponse{
+		Code:    http.StatusOK,
+		Message: "success",
+		Data:    images,
+		Total:   int64(len(images)),
+	})
+}
+
+// pastDates 从 firstWallpaperYear 到 year 之前每年的 month-day，格式为 YYYY-MM-DD
+// 2 月 29 日只出现在闰年
+func pastDates(month time.Month, day, year int) []string {
+	dates := make([]string, 0, year-firstWallpaperYear)
+	for y := firstWallpaperYear; y < year; y++ {
+		date := time.Date(y, month, day, 0, 0, 0, 0, time.UTC)
+		if date.Month() != month {
+			continue
+		}
+		dates = append(dates, date.Format("2006-01-02"))
+	}
+	return dates
+}
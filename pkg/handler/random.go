@@ -2,81 +2,365 @@ package handler
 
 import (
 	"context"
+	"log"
 	"math/rand"
 	"net/http"
+	"regexp"
+	"strconv"
 	"time"
 
+	"github.com/gclm/galaxy-bing-wallpapers/pkg/config"
 	"github.com/gclm/galaxy-bing-wallpapers/pkg/database"
+	"github.com/gclm/galaxy-bing-wallpapers/pkg/imaging"
 	"github.com/gclm/galaxy-bing-wallpapers/pkg/model"
 	"github.com/gin-gonic/gin"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/mongo"
-	"go.mongodb.org/mongo-driver/mongo/options"
+	"golang.org/x/sync/errgroup"
 )
 
+const (
+	// maxRandomCount count 参数的上限
+	maxRandomCount = 50
+	// seededFetchConcurrency 指定 seed 时同时按偏移量查询的数量
+	seededFetchConcurrency = 8
+	// clientIDHeader 客户端标识请求头，不重复模式据此记录历史，优先级低于 client 查询参数
+	clientIDHeader = "X-Client-ID"
+	// dedupeOversample 排除近似重复时先多取的倍数
+	dedupeOversample = 3
+)
+
+// resolutionPattern res 参数格式，如 1920x1080、UHD
+var resolutionPattern = regexp.MustCompile(`^(\d+x\d+|UHD)$`)
+
+// randomQuery 随机壁纸的查询参数
+type randomQuery struct {
+	filter   bson.M
+	count    int
+	seed     *int64
+	noRepeat bool
+	client   string
+	dedupe   bool
+}
+
+// GetRandomWallpaper 获取随机壁纸
+// 支持 mkt、from/to、res 过滤，seed 固定结果，count 一次返回多张互不相同的图片，
+// norepeat 在时间窗口内不返回该客户端已经拿到过的壁纸，dedupe 排除感知哈希近似的图片
 func GetRandomWallpaper(c *gin.Context) {
-	collection := database.GetCollection("wallpapers")
+	responseType, err := responseTypeQuery(c, responseTypeImage)
+	if err != nil {
+		HandleError(c, err)
+		return
+	}
+	query, err := parseRandomQuery(c)
+	if err != nil {
+		HandleError(c, err)
+		return
+	}
+	if query.count > 1 && responseType != responseTypeJSON {
+		HandleError(c, NewError(http.StatusBadRequest, CodeInvalidParameter, "count > 1 requires type=json"))
+		return
+	}
+
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
-	// 获取总数量
-	total, err := collection.CountDocuments(ctx, bson.M{})
+	wallpapers, err := pickRandomWallpapers(ctx, query)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		HandleError(c, err)
+		return
+	}
+	if len(wallpapers) == 0 {
+		HandleError(c, mongo.ErrNoDocuments)
 		return
 	}
 
-	// 生成随机索引
-	rand.Seed(time.Now().UnixNano())
-	randomIndex := rand.Intn(int(total))
+	if query.noRepeat {
+		// 记录失败不影响本次响应，最多导致之后重复
+		if err := database.RecordRandomPicks(ctx, query.client, wallpapers); err != nil {
+			log.Printf("Failed to record random picks: %v", err)
+		}
+	}
 
-	// 获取随机文档
-	var result bson.M
-	err = collection.FindOne(ctx, bson.M{}, options.FindOne().SetSkip(int64(randomIndex))).Decode(&result)
+	if query.seed == nil {
+		c.Header("Cache-Control", "no-store")
+	}
 
-	if handleMongoError(c, err) {
+	if query.count == 1 {
+		respondWallpaper(c, wallpapers[0], responseType)
 		return
 	}
 
-	redirectToImage(c, result)
+	images := make([]model.ImageResponse, len(wallpapers))
+	for i, wallpaper := range wallpapers {
+		images[i] = imageResponse(c, wallpaper)
+	}
+	c.JSON(http.StatusOK, model.ApiResponse{
+		Code:    http.StatusOK,
+		Message: "success",
+		Data:    images,
+		Total:   int64(len(images)),
+	})
 }
 
-func handleMongoError(c *gin.Context, err error) bool {
+// parseRandomQuery 读取并校验随机壁纸的查询参数
+func parseRandomQuery(c *gin.Context) (*randomQuery, error) {
+	filter, err := buildWallpaperFilter(c)
 	if err != nil {
-		if err == mongo.ErrNoDocuments {
-			c.JSON(http.StatusNotFound, gin.H{"error": "Wallpaper not found"})
-		} else {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return nil, err
+	}
+
+	query := &randomQuery{filter: filter, count: 1}
+
+	if res := c.Query("res"); res != "" {
+		if !resolutionPattern.MatchString(res) {
+			return nil, NewError(http.StatusBadRequest, CodeInvalidParameter, "res: "+res)
 		}
-		return true
+		// 只保留原图为该分辨率的壁纸
+		filter["url"] = bson.M{"$regex": "_" + regexp.QuoteMeta(res) + `\.jpg$`}
 	}
-	return false
+
+	if value := c.Query("count"); value != "" {
+		count, err := strconv.Atoi(value)
+		if err != nil || count < 1 || count > maxRandomCount {
+			return nil, NewError(http.StatusBadRequest, CodeInvalidParameter, "count must be between 1 and "+strconv.Itoa(maxRandomCount))
+		}
+		query.count = count
+	}
+
+	if value := c.Query("seed"); value != "" {
+		seed, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return nil, NewError(http.StatusBadRequest, CodeInvalidParameter, "seed: "+value)
+		}
+		query.seed = &seed
+	}
+
+	if value := c.Query("norepeat"); value != "" {
+		noRepeat, err := strconv.ParseBool(value)
+		if err != nil {
+			return nil, NewError(http.StatusBadRequest, CodeInvalidParameter, "norepeat: "+value)
+		}
+		query.noRepeat = noRepeat
+	}
+	if value := c.Query("dedupe"); value != "" {
+		dedupe, err := strconv.ParseBool(value)
+		if err != nil {
+			return nil, NewError(http.StatusBadRequest, CodeInvalidParameter, "dedupe: "+value)
+		}
+		query.dedupe = dedupe
+	}
+
+	if query.noRepeat {
+		query.client = c.Query("client")
+		if query.client == "" {
+			query.client = c.GetHeader(clientIDHeader)
+		}
+		if query.client == "" {
+			query.client = c.ClientIP()
+		}
+	}
+
+	return query, nil
 }
 
-func redirectToImage(c *gin.Context, result bson.M) {
-	width := c.DefaultQuery("w", "1920")
-	height := c.DefaultQuery("h", "1080")
+// pickRandomWallpapers 按查询参数随机选取壁纸
+// 不重复模式下排除客户端最近拿到过的壁纸，全部拿到过时不再排除，重新开始一轮
+func pickRandomWallpapers(ctx context.Context, query *randomQuery) ([]model.Wallpaper, error) {
+	wallpapers, avoid, err := pickCandidates(ctx, query)
+	if err != nil || !query.dedupe {
+		return wallpapers, err
+	}
+	return dropNearDuplicates(wallpapers, avoid, query.count), nil
+}
 
-	var wallpaper model.Wallpaper
-	bsonBytes, _ := bson.Marshal(result)
-	bson.Unmarshal(bsonBytes, &wallpaper)
+// pickCandidates 随机选取候选壁纸，dedupe 时多取一些，并返回需要避开的已拿到过的图片的 pHash
+func pickCandidates(ctx context.Context, query *randomQuery) ([]model.Wallpaper, []string, error) {
+	if query.noRepeat {
+		since := time.Now().Add(-noRepeatWindow())
+		seen, seenOHRs, err := database.RecentRandomPicks(ctx, query.client, since)
+		if err != nil {
+			return nil, nil, err
+		}
+		if len(seen) > 0 {
+			var avoid []string
+			if query.dedupe {
+				if avoid, err = seenHashes(ctx, seen); err != nil {
+					return nil, nil, err
+				}
+			}
 
-	imageURL := wallpaper.GenerateImageURL(width, height)
+			filter := bson.M{"id": bson.M{"$nin": seen}}
+			if len(seenOHRs) > 0 {
+				filter["ohr"] = bson.M{"$nin": seenOHRs}
+			}
+			for key, value := range query.filter {
+				filter[key] = value
+			}
+			wallpapers, err := sampleWallpapers(ctx, filter, query)
+			if err != nil || len(wallpapers) > 0 {
+				return wallpapers, avoid, err
+			}
+		}
+	}
+	wallpapers, err := sampleWallpapers(ctx, query.filter, query)
+	return wallpapers, nil, err
+}
 
-	responseType := c.DefaultQuery("type", "image")
+// seenHashes 已拿到过的壁纸的 pHash
+func seenHashes(ctx context.Context, ids []int) ([]string, error) {
+	refs, err := database.WallpaperHashes(ctx, bson.M{"id": bson.M{"$in": ids}})
+	if err != nil {
+		return nil, err
+	}
+	hashes := make([]string, 0, len(refs))
+	for _, ref := range refs {
+		if hash, _ := imaging.HashOf(ref.Hashes, imaging.AlgorithmPHash); hash != "" {
+			hashes = append(hashes, hash)
+		}
+	}
+	return hashes, nil
+}
 
-	switch responseType {
-	case "image":
-		c.Redirect(http.StatusFound, imageURL)
-	case "json":
-		c.JSON(http.StatusOK, model.ImageResponse{
-			Url:      imageURL,
-			Title:    wallpaper.Title,
-			Datetime: wallpaper.Datetime,
-		})
-	default:
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "Unsupported response type. Use 'image' or 'json'",
+// dropNearDuplicates 按顺序保留最多 count 张壁纸，跳过与已保留或 avoid 中的图片 pHash 近似的
+// 没有计算哈希的壁纸无法比较，直接保留；剩下的不足 count 张时用跳过的补足
+func dropNearDuplicates(wallpapers []model.Wallpaper, avoid []string, count int) []model.Wallpaper {
+	kept := make([]model.Wallpaper, 0, count)
+	var skipped []model.Wallpaper
+	hashes := append([]string(nil), avoid...)
+	for _, w := range wallpapers {
+		if len(kept) == count {
+			break
+		}
+		if hash, _ := imaging.HashOf(w.Hashes, imaging.AlgorithmPHash); hash != "" {
+			if nearAny(hash, hashes) {
+				skipped = append(skipped, w)
+				continue
+			}
+			hashes = append(hashes, hash)
+		}
+		kept = append(kept, w)
+	}
+	for _, w := range skipped {
+		if len(kept) == count {
+			break
+		}
+		kept = append(kept, w)
+	}
+	return kept
+}
+
+// nearAny hash 是否与 hashes 中的任一个近似重复
+func nearAny(hash string, hashes []string) bool {
+	for _, other := range hashes {
+		if d, ok := imaging.Distance(hash, other); ok && d <= imaging.NearDuplicateDistance {
+			return true
+		}
+	}
+	return false
+}
+
+// sampleWallpapers 未指定 seed 时使用 $sample；指定 seed 时用该种子生成按 ID 排序的候选中的偏移量逐张选取，结果可复现
+// 两种方式都不会在一次返回中包含同一张图片的多个市场版本
+func sampleWallpapers(ctx context.Context, filter bson.M, query *randomQuery) ([]model.Wallpaper, error) {
+	size := query.count
+	if query.dedupe {
+		size *= dedupeOversample
+	}
+	if query.seed == nil {
+		return database.SampleWallpapers(ctx, filter, size)
+	}
+
+	total, err := database.CountWallpapers(ctx, filter)
+	if err != nil || total == 0 {
+		return nil, err
+	}
+
+	offsets := newSeededOffsets(*query.seed, total)
+	result := make([]model.Wallpaper, 0, size)
+	pickedOHRs := make(map[string]bool, size)
+	for len(result) < size {
+		// 每轮取还差的数量，跳过重复图片后不足时再取下一轮
+		batch := offsets.next(size - len(result))
+		if len(batch) == 0 {
+			break
+		}
+		wallpapers, err := wallpapersAt(ctx, filter, batch)
+		if err != nil {
+			return nil, err
+		}
+		for _, w := range wallpapers {
+			if w == nil {
+				continue
+			}
+			if w.OHR != "" {
+				if pickedOHRs[w.OHR] {
+					continue
+				}
+				pickedOHRs[w.OHR] = true
+			}
+			result = append(result, *w)
+		}
+	}
+	return result, nil
+}
+
+// wallpapersAt 并发获取各偏移量处的壁纸，结果与 offsets 一一对应，计数之后被删除的位置为 nil
+func wallpapersAt(ctx context.Context, filter bson.M, offsets []int64) ([]*model.Wallpaper, error) {
+	wallpapers := make([]*model.Wallpaper, len(offsets))
+	group, ctx := errgroup.WithContext(ctx)
+	group.SetLimit(seededFetchConcurrency)
+	for i, offset := range offsets {
+		i, offset := i, offset
+		group.Go(func() error {
+			w, err := database.WallpaperAt(ctx, filter, offset)
+			wallpapers[i] = w
+			return err
 		})
 	}
+	if err := group.Wait(); err != nil {
+		return nil, err
+	}
+	return wallpapers, nil
+}
+
+// seededOffsets 按种子逐个生成 [0, n) 的随机排列，只记录被交换过的位置，不需要分配整个排列
+type seededOffsets struct {
+	rng     *rand.Rand
+	n       int64
+	drawn   int64
+	swapped map[int64]int64
+}
+
+func newSeededOffsets(seed, n int64) *seededOffsets {
+	return &seededOffsets{rng: rand.New(rand.NewSource(seed)), n: n, swapped: make(map[int64]int64)}
+}
+
+// next 继续 Fisher–Yates 洗牌，返回排列中接下来的最多 k 个偏移量
+func (s *seededOffsets) next(k int) []int64 {
+	var offsets []int64
+	for ; k > 0 && s.drawn < s.n; k-- {
+		j := s.drawn + s.rng.Int63n(s.n-s.drawn)
+		offsets = append(offsets, s.at(j))
+		s.swapped[j] = s.at(s.drawn)
+		delete(s.swapped, s.drawn)
+		s.drawn++
+	}
+	return offsets
+}
+
+// at 排列中位置 i 当前的偏移量
+func (s *seededOffsets) at(i int64) int64 {
+	if v, ok := s.swapped[i]; ok {
+		return v
+	}
+	return i
+}
+
+// noRepeatWindow 不重复模式的时间窗口
+func noRepeatWindow() time.Duration {
+	if config.GlobalConfig == nil {
+		return 7 * 24 * time.Hour
+	}
+	return config.GlobalConfig.RandomNoRepeatWindow
 }
@@ -0,0 +1,90 @@
+package handler
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gclm/galaxy-bing-wallpapers/pkg/model"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	responseTypeImage = "image"
+	responseTypeJSON  = "json"
+)
+
+// marketQuery 读取并校验 mkt 参数，未传时返回默认值（可为空）
+func marketQuery(c *gin.Context, defaultMkt string) (string, error) {
+	mkt := c.DefaultQuery("mkt", defaultMkt)
+	if mkt != "" && !model.IsValidMarket(mkt) {
+		return "", NewError(http.StatusBadRequest, CodeInvalidMarket, mkt)
+	}
+	return mkt, nil
+}
+
+// responseTypeQuery 读取并校验 type 参数，未传时返回默认值
+func responseTypeQuery(c *gin.Context, defaultType string) (string, error) {
+	responseType := c.DefaultQuery("type", defaultType)
+	if responseType != responseTypeImage && responseType != responseTypeJSON {
+		return "", NewError(http.StatusBadRequest, CodeUnsupportedType, responseType)
+	}
+	return responseType, nil
+}
+
+// timezoneHeader 客户端指定时区的请求头，优先级低于 tz 查询参数
+const timezoneHeader = "X-Timezone"
+
+// locationQuery 读取 tz 参数或 X-Timezone 请求头，都未指定时使用市场所在时区
+func locationQuery(c *gin.Context, mkt string) (*time.Location, error) {
+	name := c.Query("tz")
+	if name == "" {
+		name = c.GetHeader(timezoneHeader)
+	}
+	if name == "" {
+		return model.MarketLocation(mkt), nil
+	}
+
+	// time.LoadLocation 把空字符串和 Local 解析为服务器时区，这里不接受
+	loc, err := time.LoadLocation(name)
+	if err != nil || name == "Local" {
+		return nil, NewError(http.StatusBadRequest, CodeInvalidTimezone, name)
+	}
+	return loc, nil
+}
+
+// parseDate 校验 YYYY-MM-DD 格式的日期
+func parseDate(value string) (time.Time, error) {
+	date, err := time.Parse("2006-01-02", value)
+	if err != nil {
+		return time.Time{}, NewError(http.StatusBadRequest, CodeInvalidDate, value)
+	}
+	return date, nil
+}
+
+// respondWallpaper 按 type 参数重定向到图片或返回图片信息
+func respondWallpaper(c *gin.Context, wallpaper model.Wallpaper, responseType string) {
+	image := imageResponse(c, wallpaper)
+
+	switch responseType {
+	case responseTypeJSON:
+		c.JSON(http.StatusOK, image)
+	default:
+		c.Redirect(http.StatusFound, image.Url)
+	}
+}
+
+// imageResponse 按 w/h 参数生成图片信息
+func imageResponse(c *gin.Context, wallpaper model.Wallpaper) model.ImageResponse {
+	width := c.DefaultQuery("w", "1920")
+	height := c.DefaultQuery("h", "1080")
+
+	return model.ImageResponse{
+		Url:      wallpaper.GenerateImageURL(width, height),
+		Title:    wallpaper.Title,
+		Datetime: wallpaper.Datetime,
+		Color:    wallpaper.Color,
+		Palette:  wallpaper.Palette,
+		BlurHash: wallpaper.BlurHash,
+		LQIP:     wallpaper.LQIP,
+	}
+}
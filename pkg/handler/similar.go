@@ -0,0 +1,169 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/gclm/galaxy-bing-wallpapers/pkg/database"
+	"github.com/gclm/galaxy-bing-wallpapers/pkg/imaging"
+	"github.com/gclm/galaxy-bing-wallpapers/pkg/model"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+const (
+	// defaultSimilarLimit 相似壁纸默认返回的数量
+	defaultSimilarLimit = 20
+	// maxSimilarLimit limit 参数的上限
+	maxSimilarLimit = 100
+	// maxSimilarDistance distance 参数的上限，64 位哈希超过一半的位不同时已与随机图片无异
+	maxSimilarDistance = 32
+)
+
+// GetSimilarWallpapers 按感知哈希的汉明距离查找与指定壁纸相似的图片
+// 同一张图片的其他市场版本不算在内，每张相似的图片只返回一个版本（优先与源壁纸相同市场的）
+func GetSimilarWallpapers(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil || id < 1 {
+		HandleError(c, NewError(http.StatusBadRequest, CodeInvalidParameter, "id: "+c.Param("id")))
+		return
+	}
+
+	algorithm := c.DefaultQuery("algorithm", imaging.AlgorithmPHash)
+	if _, ok := imaging.HashOf(&model.ImageHashes{}, algorithm); !ok {
+		HandleError(c, NewError(http.StatusBadRequest, CodeInvalidParameter, "algorithm must be ahash, dhash or phash"))
+		return
+	}
+
+	distance := imaging.NearDuplicateDistance
+	if value := c.Query("distance"); value != "" {
+		distance, err = strconv.Atoi(value)
+		if err != nil || distance < 0 || distance > maxSimilarDistance {
+			HandleError(c, NewError(http.StatusBadRequest, CodeInvalidParameter, "distance must be between 0 and "+strconv.Itoa(maxSimilarDistance)))
+			return
+		}
+	}
+
+	limit := defaultSimilarLimit
+	if value := c.Query("limit"); value != "" {
+		limit, err = strconv.Atoi(value)
+		if err != nil || limit < 1 || limit > maxSimilarLimit {
+			HandleError(c, NewError(http.StatusBadRequest, CodeInvalidParameter, "limit must be between 1 and "+strconv.Itoa(maxSimilarLimit)))
+			return
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	var source model.Wallpaper
+	if err := database.GetCollection("wallpapers").FindOne(ctx, bson.M{"id": id}).Decode(&source); err != nil {
+		HandleError(c, err)
+		return
+	}
+	hash, _ := imaging.HashOf(source.Hashes, algorithm)
+	if hash == "" {
+		HandleError(c, NewError(http.StatusConflict, CodeImageNotAnalyzed, "wallpaper "+strconv.Itoa(id)+" has no perceptual hashes"))
+		return
+	}
+
+	candidates, err := database.WallpaperHashes(ctx, bson.M{"id": bson.M{"$ne": id}})
+	if err != nil {
+		HandleError(c, err)
+		return
+	}
+	matches := nearestImages(source, hash, algorithm, candidates, distance)
+	if len(matches) > limit {
+		matches = matches[:limit]
+	}
+
+	similar, err := loadSimilarWallpapers(ctx, matches)
+	if err != nil {
+		HandleError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, model.ApiResponse{
+		Code:    http.StatusOK,
+		Message: "success",
+		Data:    similar,
+		Total:   int64(len(similar)),
+	})
+}
+
+// similarMatch 相似的壁纸 ID 及距离
+type similarMatch struct {
+	id       int
+	distance int
+}
+
+// nearestImages 找出距离不超过 maxDistance 的图片，按图片标识去重，按距离升序、ID 升序
+func nearestImages(source model.Wallpaper, hash, algorithm string, candidates []database.WallpaperHash, maxDistance int) []similarMatch {
+	type best struct {
+		similarMatch
+		sameMarket bool
+	}
+	byImage := make(map[string]best)
+	for _, candidate := range candidates {
+		if source.OHR != "" && candidate.OHR == source.OHR {
+			continue
+		}
+		other, _ := imaging.HashOf(candidate.Hashes, algorithm)
+		d, ok := imaging.Distance(hash, other)
+		if !ok || d > maxDistance {
+			continue
+		}
+
+		key := candidate.OHR
+		if key == "" {
+			key = "id:" + strconv.Itoa(candidate.ID)
+		}
+		current := best{similarMatch{id: candidate.ID, distance: d}, candidate.Mkt == source.Mkt}
+		// 候选按 ID 升序，同一张图片只在距离更小或首次遇到源市场版本时替换
+		if prev, ok := byImage[key]; ok && (prev.distance < d || prev.distance == d && (prev.sameMarket || !current.sameMarket)) {
+			continue
+		}
+		byImage[key] = current
+	}
+
+	matches := make([]similarMatch, 0, len(byImage))
+	for _, b := range byImage {
+		matches = append(matches, b.similarMatch)
+	}
+	sort.Slice(matches, func(i, j int) bool {
+		if matches[i].distance != matches[j].distance {
+			return matches[i].distance < matches[j].distance
+		}
+		return matches[i].id < matches[j].id
+	})
+	return matches
+}
+
+// loadSimilarWallpapers 读取匹配到的壁纸，保持 matches 的顺序
+func loadSimilarWallpapers(ctx context.Context, matches []similarMatch) ([]model.SimilarWallpaper, error) {
+	similar := make([]model.SimilarWallpaper, 0, len(matches))
+	if len(matches) == 0 {
+		return similar, nil
+	}
+
+	ids := make([]int, len(matches))
+	for i, match := range matches {
+		ids[i] = match.id
+	}
+	wallpapers, err := database.ListWallpapers(ctx, bson.M{"id": bson.M{"$in": ids}}, bson.D{{Key: "id", Value: 1}})
+	if err != nil {
+		return nil, err
+	}
+	byID := make(map[int]model.Wallpaper, len(wallpapers))
+	for _, w := range wallpapers {
+		byID[w.ID] = w
+	}
+	for _, match := range matches {
+		if w, ok := byID[match.id]; ok {
+			similar = append(similar, model.SimilarWallpaper{Distance: match.distance, Wallpaper: w})
+		}
+	}
+	return similar, nil
+}
@@ -0,0 +1,128 @@
+package handler
+
+import (
+	"context"
+	"math"
+	"net/http"
+	"time"
+
+	"github.com/gclm/galaxy-bing-wallpapers/pkg/database"
+	"github.com/gclm/galaxy-bing-wallpapers/pkg/model"
+	"github.com/gin-gonic/gin"
+)
+
+// statsTop 统计中图片库和摄影师各列出的数量
+const statsTop = 10
+
+// GetStats 获取壁纸库的统计信息：各市场数量、日期范围和覆盖率，常见图片库和摄影师，
+// 图片跨市场复用情况，以及各市场的抓取成功率
+func GetStats(c *gin.Context) {
+	respondCached(c, func(ctx context.Context) (model.ApiResponse, error) {
+		stats, err := collectStats(ctx)
+		if err != nil {
+			return model.ApiResponse{}, err
+		}
+		return model.ApiResponse{
+			Code:    http.StatusOK,
+			Message: "success",
+			Data:    stats,
+		}, nil
+	})
+}
+
+// collectStats 执行聚合并计算覆盖率、成功率等派生字段
+func collectStats(ctx context.Context) (*model.Stats, error) {
+	wallpapers, err := database.AggregateWallpaperStats(ctx, statsTop)
+	if err != nil {
+		return nil, err
+	}
+	fetches, err := database.AggregateFetchStats(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	stats := &model.Stats{
+		Markets:       sortByMarket(wallpapers.Markets, func(s model.MarketStats) string { return s.Mkt }),
+		Agencies:      nonNil(wallpapers.Agencies),
+		Photographers: nonNil(wallpapers.Photographers),
+		Reuse:         reuseStats(wallpapers.Reuse),
+		Fetch:         sortByMarket(fetches, func(s model.FetchStats) string { return s.Mkt }),
+	}
+	for i := range stats.Markets {
+		m := &stats.Markets[i]
+		stats.Total += m.Count
+		days := spanDays(m.First, m.Last)
+		if days > 0 {
+			m.Coverage = percent(m.Count, int64(days))
+			m.GapDays = max(days-int(m.Count), 0)
+		}
+	}
+	for i := range stats.Fetch {
+		f := &stats.Fetch[i]
+		f.SuccessRate = percent(f.Successes, f.Attempts)
+	}
+	return stats, nil
+}
+
+// reuseStats 由按市场数分组的图片数量计算复用统计
+func reuseStats(buckets []model.ReuseBucket) model.ReuseStats {
+	reuse := model.ReuseStats{Distribution: nonNil(buckets)}
+	var markets int64
+	for _, b := range buckets {
+		reuse.Images += b.Images
+		markets += int64(b.Markets) * b.Images
+		if b.Markets > 1 {
+			reuse.Shared += b.Images
+		}
+	}
+	if reuse.Images > 0 {
+		reuse.AvgMarkets = math.Round(float64(markets)/float64(reuse.Images)*100) / 100
+	}
+	return reuse
+}
+
+// spanDays first 到 last（YYYY-MM-DD，包含两端）的天数，日期无效时返回 0
+func spanDays(first, last string) int {
+	from, errFrom := time.Parse("2006-01-02", first)
+	to, errTo := time.Parse("2006-01-02", last)
+	if errFrom != nil || errTo != nil || to.Before(from) {
+		return 0
+	}
+	return int(to.Sub(from).Hours()/24) + 1
+}
+
+// percent 百分比，保留两位小数，total 为 0 时返回 0
+func percent(part, total int64) float64 {
+	if total == 0 {
+		return 0
+	}
+	return math.Round(float64(part)/float64(total)*10000) / 100
+}
+
+// sortByMarket 按支持的市场顺序排列，不在列表中的市场放在最后
+func sortByMarket[T any](items []T, mkt func(T) string) []T {
+	result := make([]T, 0, len(items))
+	used := make([]bool, len(items))
+	for _, market := range model.Markets {
+		for i, item := range items {
+			if !used[i] && mkt(item) == market {
+				result = append(result, item)
+				used[i] = true
+			}
+		}
+	}
+	for i, item := range items {
+		if !used[i] {
+			result = append(result, item)
+		}
+	}
+	return result
+}
+
+// nonNil 空结果序列化为 [] 而不是 null
+func nonNil[T any](items []T) []T {
+	if items == nil {
+		return []T{}
+	}
+	return items
+}
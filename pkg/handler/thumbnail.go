@@ -0,0 +1,125 @@
+package handler
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gclm/galaxy-bing-wallpapers/pkg/config"
+	"github.com/gclm/galaxy-bing-wallpapers/pkg/database"
+	"github.com/gclm/galaxy-bing-wallpapers/pkg/model"
+	"github.com/gclm/galaxy-bing-wallpapers/pkg/thumbnail"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// thumbnailCacheControl 缩略图的缓存策略，同一 ID 的壁纸图片不会变化
+const thumbnailCacheControl = "public, max-age=2592000"
+
+var (
+	thumbnailOnce  sync.Once
+	thumbnailStore *thumbnail.Store
+	thumbnailSizes []thumbnail.Size
+)
+
+// GetWallpaperThumbnail 获取壁纸的缩略图
+// size 必须是配置的尺寸之一，默认为第一个；首次请求时生成并缓存，之后直接返回文件
+func GetWallpaperThumbnail(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil || id < 1 {
+		HandleError(c, NewError(http.StatusBadRequest, CodeInvalidParameter, "id: "+c.Param("id")))
+		return
+	}
+
+	store, sizes := getThumbnails()
+	size := sizes[0]
+	if value := c.Query("size"); value != "" {
+		var ok bool
+		if size, ok = findThumbnailSize(sizes, value); !ok {
+			HandleError(c, NewError(http.StatusBadRequest, CodeInvalidParameter, "size must be one of "+joinSizes(sizes)))
+			return
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+
+	var wallpaper model.Wallpaper
+	if err := database.GetCollection("wallpapers").FindOne(ctx, bson.M{"id": id}).Decode(&wallpaper); err != nil {
+		HandleError(c, err)
+		return
+	}
+
+	path, err := store.Path(ctx, wallpaper, size)
+	if err != nil {
+		HandleError(c, &APIError{
+			Status: http.StatusServiceUnavailable,
+			Code:   CodeServiceUnavailable,
+			Detail: "original image unavailable or too many thumbnails being generated",
+			Err:    err,
+		})
+		return
+	}
+
+	c.Header("Cache-Control", thumbnailCacheControl)
+	c.File(path)
+}
+
+// thumbnailURLs 壁纸各尺寸缩略图的地址，按尺寸索引
+func thumbnailURLs(wallpaper model.Wallpaper) map[string]string {
+	_, sizes := getThumbnails()
+	urls := make(map[string]string, len(sizes))
+	for _, size := range sizes {
+		urls[size.String()] = "/api/v1/wallpapers/" + strconv.Itoa(wallpaper.ID) + "/thumb?size=" + size.String()
+	}
+	return urls
+}
+
+// getThumbnails 缩略图缓存和允许的尺寸，无效的尺寸只记录日志
+func getThumbnails() (*thumbnail.Store, []thumbnail.Size) {
+	thumbnailOnce.Do(func() {
+		values := []string{"320x180", "640x360", "1280x720"}
+		dir := filepath.Join(os.TempDir(), "galaxy-bing-thumbnails")
+		var mirror string
+		if cfg := config.GlobalConfig; cfg != nil {
+			values, dir, mirror = cfg.ThumbnailSizes, cfg.ThumbnailDir, cfg.ImageMirrorDir
+		}
+
+		for _, value := range values {
+			size, err := thumbnail.ParseSize(value)
+			if err != nil {
+				log.Printf("Warning: %v", err)
+				continue
+			}
+			thumbnailSizes = append(thumbnailSizes, size)
+		}
+		if len(thumbnailSizes) == 0 {
+			thumbnailSizes = []thumbnail.Size{{Width: 320, Height: 180}}
+		}
+		thumbnailStore = thumbnail.New(dir, mirror)
+	})
+	return thumbnailStore, thumbnailSizes
+}
+
+func findThumbnailSize(sizes []thumbnail.Size, value string) (thumbnail.Size, bool) {
+	for _, size := range sizes {
+		if size.String() == value {
+			return size, true
+		}
+	}
+	return thumbnail.Size{}, false
+}
+
+func joinSizes(sizes []thumbnail.Size) string {
+	names := make([]string, len(sizes))
+	for i, size := range sizes {
+		names[i] = size.String()
+	}
+	return strings.Join(names, ", ")
+}
@@ -2,52 +2,153 @@ package handler
 
 import (
 	"context"
-	"net/http"
+	"fmt"
 	"time"
 
+	"github.com/gclm/galaxy-bing-wallpapers/pkg/config"
 	"github.com/gclm/galaxy-bing-wallpapers/pkg/database"
 	"github.com/gclm/galaxy-bing-wallpapers/pkg/model"
 
 	"github.com/gin-gonic/gin"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
-func GetTodayWallpaper(c *gin.Context) {
-	collection := database.GetCollection("wallpapers")
+// 今日壁纸的回退策略
+const (
+	fallbackLatest = "latest" // 该市场最近一张壁纸
+	fallbackMarket = "market" // 其他市场的今日壁纸
+)
+
+// fallbackHeader 使用回退时的响应头，值为 latest 或 market=<mkt>
+const fallbackHeader = "X-Wallpaper-Fallback"
 
+// fallbackMaxAge 使用回退时的缓存时间，抓取完成后客户端能尽快拿到真正的今日壁纸
+const fallbackMaxAge = 5 * time.Minute
+
+func GetTodayWallpaper(c *gin.Context) {
 	// 处理查询参数
-	width := c.DefaultQuery("w", "1920")
-	height := c.DefaultQuery("h", "1080")
-	mkt := c.DefaultQuery("mkt", "zh-CN")
-	responseType := c.DefaultQuery("type", "image")
+	mkt, err := marketQuery(c, "zh-CN")
+	if err != nil {
+		HandleError(c, err)
+		return
+	}
+	responseType, err := responseTypeQuery(c, responseTypeImage)
+	if err != nil {
+		HandleError(c, err)
+		return
+	}
+
+	loc, err := locationQuery(c, mkt)
+	if err != nil {
+		HandleError(c, err)
+		return
+	}
+
+	// 按市场（或客户端指定）时区计算今天
+	now := time.Now().In(loc)
+	today := now.Format("2006-01-02")
+
+	wallpaper, fallback, err := findTodayWallpaper(context.Background(), mkt, today)
+	if err != nil {
+		HandleError(c, err)
+		return
+	}
+
+	// 缓存到该时区的午夜为止
+	maxAge := untilMidnight(now)
+	if fallback != "" {
+		c.Header(fallbackHeader, fallback)
+		if maxAge > fallbackMaxAge {
+			maxAge = fallbackMaxAge
+		}
+	}
+	c.Header("Cache-Control", fmt.Sprintf("public, max-age=%d", int(maxAge.Seconds())))
+	c.Writer.Header().Add("Vary", timezoneHeader)
 
+	respondWallpaper(c, wallpaper, responseType)
+}
+
+// untilMidnight 距离 now 所在时区下一个午夜的时长
+func untilMidnight(now time.Time) time.Duration {
+	year, month, day := now.Date()
+	return time.Date(year, month, day+1, 0, 0, 0, 0, now.Location()).Sub(now)
+}
+
+// findTodayWallpaper 查询市场的今日壁纸，不存在时按配置的策略依次回退
+// 返回使用的回退（未回退时为空）；只有 ErrNoDocuments 会触发回退，其他错误直接返回
+func findTodayWallpaper(ctx context.Context, mkt, today string) (model.Wallpaper, string, error) {
+	wallpaper, err := findToday(ctx, mkt, today)
+	if err != mongo.ErrNoDocuments {
+		return wallpaper, "", err
+	}
+
+	for _, strategy := range todayFallback() {
+		switch strategy {
+		case fallbackLatest:
+			wallpaper, err = findLatest(ctx, mkt)
+			if err == nil {
+				return wallpaper, fallbackLatest, nil
+			}
+			if err != mongo.ErrNoDocuments {
+				return model.Wallpaper{}, "", err
+			}
+		case fallbackMarket:
+			for _, other := range fallbackMarkets(mkt) {
+				wallpaper, err = findToday(ctx, other, today)
+				if err == nil {
+					return wallpaper, fallbackMarket + "=" + other, nil
+				}
+				if err != mongo.ErrNoDocuments {
+					return model.Wallpaper{}, "", err
+				}
+			}
+		}
+	}
+
+	return model.Wallpaper{}, "", mongo.ErrNoDocuments
+}
+
+// findToday 查询市场中日期不早于 today 的第一张壁纸
+func findToday(ctx context.Context, mkt, today string) (model.Wallpaper, error) {
 	var wallpaper model.Wallpaper
-	ctx := context.Background()
-	err := collection.FindOne(ctx, bson.M{
-		"datetime": bson.M{"$gte": time.Now().Format("2006-01-02")},
+	err := database.GetCollection("wallpapers").FindOne(ctx, bson.M{
+		"datetime": bson.M{"$gte": today},
 		"mkt":      mkt,
-	}).Decode(&wallpaper)
+	}, options.FindOne().SetSort(bson.D{{Key: "datetime", Value: 1}})).Decode(&wallpaper)
+	return wallpaper, err
+}
 
-	if err == mongo.ErrNoDocuments {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Wallpaper not found"})
-		return
+// findLatest 查询市场最近一张壁纸
+func findLatest(ctx context.Context, mkt string) (model.Wallpaper, error) {
+	var wallpaper model.Wallpaper
+	err := database.GetCollection("wallpapers").FindOne(ctx, bson.M{
+		"mkt": mkt,
+	}, options.FindOne().SetSort(bson.D{{Key: "datetime", Value: -1}})).Decode(&wallpaper)
+	return wallpaper, err
+}
+
+// todayFallback 配置的回退策略
+func todayFallback() []string {
+	if config.GlobalConfig == nil {
+		return []string{fallbackLatest, fallbackMarket}
 	}
+	return config.GlobalConfig.TodayFallback
+}
 
-	imageURL := wallpaper.GenerateImageURL(width, height)
+// fallbackMarkets market 策略依次尝试的市场，不包括 mkt 本身
+func fallbackMarkets(mkt string) []string {
+	markets := model.Markets
+	if config.GlobalConfig != nil && len(config.GlobalConfig.TodayFallbackMarkets) > 0 {
+		markets = config.GlobalConfig.TodayFallbackMarkets
+	}
 
-	switch responseType {
-	case "image":
-		c.Redirect(http.StatusFound, imageURL)
-	case "json":
-		c.JSON(http.StatusOK, model.ImageResponse{
-			Url:      imageURL,
-			Title:    wallpaper.Title,
-			Datetime: wallpaper.Datetime,
-		})
-	default:
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "Unsupported response type. Use 'image' or 'json'",
-		})
+	var result []string
+	for _, other := range markets {
+		if other != mkt && model.IsValidMarket(other) {
+			result = append(result, other)
+		}
 	}
+	return result
 }
@@ -0,0 +1,149 @@
+package imaging
+
+import (
+	"fmt"
+	"image"
+	"math"
+	"math/bits"
+	"sort"
+	"strconv"
+
+	"github.com/gclm/galaxy-bing-wallpapers/pkg/model"
+)
+
+// 感知哈希的算法名称
+const (
+	AlgorithmAHash = "ahash"
+	AlgorithmDHash = "dhash"
+	AlgorithmPHash = "phash"
+)
+
+// NearDuplicateDistance 64 位 pHash 的汉明距离不超过该值时视为近似重复的图片
+const NearDuplicateDistance = 10
+
+// Hashes 计算三种 64 位感知哈希，格式为 16 位十六进制
+// 必应的 hsh 字段是每次上传的哈希，同一张照片隔几年重新使用时并不相同，感知哈希则基本不变
+func Hashes(img image.Image) *model.ImageHashes {
+	return &model.ImageHashes{
+		AHash: formatHash(averageHash(img)),
+		DHash: formatHash(differenceHash(img)),
+		PHash: formatHash(perceptualHash(img)),
+	}
+}
+
+// Distance 两个十六进制哈希的汉明距离，格式无效时返回 false
+func Distance(a, b string) (int, bool) {
+	x, errA := strconv.ParseUint(a, 16, 64)
+	y, errB := strconv.ParseUint(b, 16, 64)
+	if errA != nil || errB != nil {
+		return 0, false
+	}
+	return bits.OnesCount64(x ^ y), true
+}
+
+// HashOf 按算法名称取出哈希，算法不支持时返回 false
+func HashOf(hashes *model.ImageHashes, algorithm string) (string, bool) {
+	if hashes == nil {
+		return "", false
+	}
+	switch algorithm {
+	case AlgorithmAHash:
+		return hashes.AHash, true
+	case AlgorithmDHash:
+		return hashes.DHash, true
+	case AlgorithmPHash:
+		return hashes.PHash, true
+	}
+	return "", false
+}
+
+// averageHash 缩小为 8x8 灰度，每位表示像素是否高于平均亮度
+func averageHash(img image.Image) uint64 {
+	gray := grayscale(img, 8, 8)
+	var sum float64
+	for _, v := range gray {
+		sum += v
+	}
+	mean := sum / float64(len(gray))
+
+	var hash uint64
+	for i, v := range gray {
+		if v > mean {
+			hash |= 1 << uint(63-i)
+		}
+	}
+	return hash
+}
+
+// differenceHash 缩小为 9x8 灰度，每位表示像素是否比右侧相邻像素暗
+func differenceHash(img image.Image) uint64 {
+	gray := grayscale(img, 9, 8)
+	var hash uint64
+	bit := 63
+	for y := 0; y < 8; y++ {
+		for x := 0; x < 8; x++ {
+			if gray[y*9+x] < gray[y*9+x+1] {
+				hash |= 1 << uint(bit)
+			}
+			bit--
+		}
+	}
+	return hash
+}
+
+// perceptualHash 缩小为 32x32 灰度做二维 DCT，取左上角 8x8 低频系数，每位表示系数是否高于中位数
+// 中位数不含直流分量，直流分量只反映整体亮度
+func perceptualHash(img image.Image) uint64 {
+	const size, low = 32, 8
+	gray := grayscale(img, size, size)
+
+	// 先对每行、再对每列做一维 DCT，只需要前 low 个系数
+	rows := make([]float64, size*low)
+	for y := 0; y < size; y++ {
+		for u := 0; u < low; u++ {
+			rows[y*low+u] = dct(func(x int) float64 { return gray[y*size+x] }, u, size)
+		}
+	}
+	coeffs := make([]float64, low*low)
+	for u := 0; u < low; u++ {
+		for v := 0; v < low; v++ {
+			coeffs[v*low+u] = dct(func(y int) float64 { return rows[y*low+u] }, v, size)
+		}
+	}
+
+	sorted := append([]float64(nil), coeffs[1:]...)
+	sort.Float64s(sorted)
+	median := (sorted[len(sorted)/2-1] + sorted[len(sorted)/2]) / 2
+
+	var hash uint64
+	for i, v := range coeffs {
+		if v > median {
+			hash |= 1 << uint(63-i)
+		}
+	}
+	return hash
+}
+
+// dct 一维 DCT-II 的第 k 个系数（未归一化，不影响与中位数的比较）
+func dct(value func(int) float64, k, n int) float64 {
+	var sum float64
+	for i := 0; i < n; i++ {
+		sum += value(i) * math.Cos(math.Pi*float64(k)*(2*float64(i)+1)/(2*float64(n)))
+	}
+	return sum
+}
+
+// grayscale 缩放到 width x height 并转换为亮度（BT.601），按行排列
+func grayscale(img image.Image, width, height int) []float64 {
+	small := Resize(img, width, height)
+	gray := make([]float64, width*height)
+	for i := range gray {
+		r, g, b := small.Pix[i*4], small.Pix[i*4+1], small.Pix[i*4+2]
+		gray[i] = 0.299*float64(r) + 0.587*float64(g) + 0.114*float64(b)
+	}
+	return gray
+}
+
+func formatHash(hash uint64) string {
+	return fmt.Sprintf("%016x", hash)
+}
@@ -0,0 +1,170 @@
+// Package imaging 下载并解码壁纸图片，计算由像素得到的特征，如主色调、调色板、感知哈希和占位图
+//
+// 只依赖标准库：缩放使用区域平均，足够用于缩小后提取特征。
+// 抓取新壁纸时计算特征，已有数据由 cmd/backfill 补全。
+package imaging
+
+import (
+	"context"
+	"fmt"
+	"image"
+	"image/color"
+	_ "image/jpeg" // 注册 JPEG 解码器
+	_ "image/png"  // 注册 PNG 解码器
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/gclm/galaxy-bing-wallpapers/pkg/model"
+)
+
+// maxImageBytes 下载图片的大小上限，必应 UHD 原图一般不超过 10MB
+const maxImageBytes = 32 << 20
+
+// httpClient 下载图片使用的客户端
+var httpClient = &http.Client{Timeout: 60 * time.Second}
+
+// Download 下载并解码图片
+func Download(ctx context.Context, url string) (image.Image, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %v", err)
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download image: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status code %d for %s", resp.StatusCode, url)
+	}
+	return Decode(io.LimitReader(resp.Body, maxImageBytes))
+}
+
+// Decode 解码 JPEG 或 PNG 图片
+func Decode(r io.Reader) (image.Image, error) {
+	img, _, err := image.Decode(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode image: %v", err)
+	}
+	return img, nil
+}
+
+// Annotate 由图片计算壁纸的派生特征，覆盖已有的值
+func Annotate(wallpaper *model.Wallpaper, img image.Image) {
+	wallpaper.Palette = Palette(img)
+	wallpaper.Color = ""
+	if len(wallpaper.Palette) > 0 {
+		wallpaper.Color = wallpaper.Palette[0].Color
+	}
+	wallpaper.Hashes = Hashes(img)
+	wallpaper.BlurHash = BlurHash(img)
+	wallpaper.LQIP = Preview(img)
+}
+
+// AnnotateURL 下载壁纸图片并计算派生特征
+func AnnotateURL(ctx context.Context, wallpaper *model.Wallpaper) error {
+	img, err := Download(ctx, wallpaper.Url)
+	if err != nil {
+		return err
+	}
+	Annotate(wallpaper, img)
+	return nil
+}
+
+// Resize 缩放到 width x height，缩小时使用区域平均，放大时取最近的像素
+func Resize(img image.Image, width, height int) *image.RGBA {
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	bounds := img.Bounds()
+	sw, sh := bounds.Dx(), bounds.Dy()
+	if sw == 0 || sh == 0 || width == 0 || height == 0 {
+		return dst
+	}
+
+	if width > sw || height > sh {
+		for y := 0; y < height; y++ {
+			for x := 0; x < width; x++ {
+				dst.Set(x, y, img.At(bounds.Min.X+x*sw/width, bounds.Min.Y+y*sh/height))
+			}
+		}
+		return dst
+	}
+
+	// 每个源像素累加到它所在的目标像素
+	sums := make([][4]uint64, width*height)
+	counts := make([]uint64, width*height)
+	for y := 0; y < sh; y++ {
+		row := (y * height / sh) * width
+		for x := 0; x < sw; x++ {
+			r, g, b, a := pixel(img, bounds.Min.X+x, bounds.Min.Y+y)
+			i := row + x*width/sw
+			sums[i][0] += uint64(r)
+			sums[i][1] += uint64(g)
+			sums[i][2] += uint64(b)
+			sums[i][3] += uint64(a)
+			counts[i]++
+		}
+	}
+	for i, sum := range sums {
+		n := counts[i]
+		dst.Pix[i*4] = uint8(sum[0] / n)
+		dst.Pix[i*4+1] = uint8(sum[1] / n)
+		dst.Pix[i*4+2] = uint8(sum[2] / n)
+		dst.Pix[i*4+3] = uint8(sum[3] / n)
+	}
+	return dst
+}
+
+// Fit 按比例缩小到不超过 maxWidth x maxHeight，图片已经足够小时只转换为 RGBA
+func Fit(img image.Image, maxWidth, maxHeight int) *image.RGBA {
+	bounds := img.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	if width > maxWidth {
+		height = max(height*maxWidth/width, 1)
+		width = maxWidth
+	}
+	if height > maxHeight {
+		width = max(width*maxHeight/height, 1)
+		height = maxHeight
+	}
+	return Resize(img, width, height)
+}
+
+// Cover 居中裁切为 width:height 的比例后缩放到 width x height，与 CSS 的 object-fit: cover 相同
+func Cover(img image.Image, width, height int) *image.RGBA {
+	bounds := img.Bounds()
+	sw, sh := bounds.Dx(), bounds.Dy()
+	crop := bounds
+	if sw*height > sh*width {
+		cw := sh * width / height
+		crop.Min.X += (sw - cw) / 2
+		crop.Max.X = crop.Min.X + cw
+	} else if sw*height < sh*width {
+		ch := sw * height / width
+		crop.Min.Y += (sh - ch) / 2
+		crop.Max.Y = crop.Min.Y + ch
+	}
+	// JPEG 解码得到的 YCbCr 和 RGBA 都支持 SubImage，不复制像素
+	if sub, ok := img.(interface {
+		SubImage(image.Rectangle) image.Image
+	}); ok {
+		img = sub.SubImage(crop)
+	}
+	return Resize(img, width, height)
+}
+
+// pixel 读取 8 位 RGBA，JPEG 解码得到的 YCbCr 图片直接换算，避免逐像素的接口调用
+func pixel(img image.Image, x, y int) (uint8, uint8, uint8, uint8) {
+	switch m := img.(type) {
+	case *image.YCbCr:
+		yi, ci := m.YOffset(x, y), m.COffset(x, y)
+		r, g, b := color.YCbCrToRGB(m.Y[yi], m.Cb[ci], m.Cr[ci])
+		return r, g, b, 0xff
+	case *image.RGBA:
+		i := m.PixOffset(x, y)
+		return m.Pix[i], m.Pix[i+1], m.Pix[i+2], m.Pix[i+3]
+	}
+	c := color.RGBAModel.Convert(img.At(x, y)).(color.RGBA)
+	return c.R, c.G, c.B, c.A
+}
@@ -0,0 +1,171 @@
+package imaging
+
+import (
+	"image"
+	"image/color"
+	"math"
+	"sort"
+
+	"github.com/gclm/galaxy-bing-wallpapers/pkg/model"
+)
+
+const (
+	// paletteSample 提取调色板前缩小到的宽度（高度按比例）
+	paletteSample = 128
+	// maxSwatches 调色板最多的颜色数，中位切分得到这么多个区域
+	maxSwatches = 8
+	// minSwatches 合并相近颜色后至少保留的颜色数
+	minSwatches = 5
+	// mergeDeltaE 颜色多于 minSwatches 时，色差小于该值的两个颜色合并为一个
+	mergeDeltaE = 10
+	// sameDeltaE 色差小于该值时人眼无法分辨，总是合并
+	sameDeltaE = 2.3
+)
+
+// box 中位切分中的一个颜色区域
+type box []color.RGBA
+
+// Palette 用中位切分提取 5-8 个主要颜色及占比，按占比降序，第一个为主色调
+// 先切分为 8 个区域，再合并色差很小的颜色，但不少于 5 个（颜色过少、无法分出 5 种可分辨颜色的图片除外）
+func Palette(img image.Image) []model.Swatch {
+	small := Fit(img, paletteSample, paletteSample)
+	pixels := make(box, 0, len(small.Pix)/4)
+	for i := 0; i < len(small.Pix); i += 4 {
+		// 忽略透明像素，必应壁纸是 JPEG，一般不会有
+		if small.Pix[i+3] < 0x80 {
+			continue
+		}
+		pixels = append(pixels, color.RGBA{R: small.Pix[i], G: small.Pix[i+1], B: small.Pix[i+2], A: 0xff})
+	}
+	if len(pixels) == 0 {
+		return nil
+	}
+
+	boxes := []box{pixels}
+	for len(boxes) < maxSwatches {
+		i := widestBox(boxes)
+		if i < 0 {
+			break
+		}
+		a, b := boxes[i].split()
+		boxes[i] = a
+		boxes = append(boxes, b)
+	}
+
+	type swatch struct {
+		rgb   color.RGBA
+		lab   Lab
+		count int
+	}
+	swatches := make([]swatch, len(boxes))
+	for i, b := range boxes {
+		rgb := b.mean()
+		swatches[i] = swatch{rgb: rgb, lab: ToLab(rgb), count: len(b)}
+	}
+
+	// 合并最相近的一对颜色，直到没有足够相近的或只剩 minSwatches 个；无法分辨的颜色总是合并
+	for len(swatches) > 1 {
+		bi, bj, best := -1, -1, math.MaxFloat64
+		for i := range swatches {
+			for j := i + 1; j < len(swatches); j++ {
+				if d := DeltaE(swatches[i].lab, swatches[j].lab); d < best {
+					bi, bj, best = i, j, d
+				}
+			}
+		}
+		if best >= sameDeltaE && (best >= mergeDeltaE || len(swatches) <= minSwatches) {
+			break
+		}
+		a, b := swatches[bi], swatches[bj]
+		rgb := weightedMean(a.rgb, a.count, b.rgb, b.count)
+		swatches[bi] = swatch{rgb: rgb, lab: ToLab(rgb), count: a.count + b.count}
+		swatches = append(swatches[:bj], swatches[bj+1:]...)
+	}
+
+	sort.SliceStable(swatches, func(i, j int) bool { return swatches[i].count > swatches[j].count })
+	palette := make([]model.Swatch, len(swatches))
+	for i, s := range swatches {
+		palette[i] = model.Swatch{
+			Color:      Hex(s.rgb),
+			Proportion: math.Round(float64(s.count)/float64(len(pixels))*1000) / 1000,
+			L:          round2(s.lab.L),
+			A:          round2(s.lab.A),
+			B:          round2(s.lab.B),
+		}
+	}
+	return palette
+}
+
+// widestBox 找出最值得切分的区域：最大通道范围乘以像素数最大，没有可切分的区域时返回 -1
+func widestBox(boxes []box) int {
+	best, bestScore := -1, 0
+	for i, b := range boxes {
+		if len(b) < 2 {
+			continue
+		}
+		_, spread := b.widestChannel()
+		if score := spread * len(b); spread > 0 && score > bestScore {
+			best, bestScore = i, score
+		}
+	}
+	return best
+}
+
+// widestChannel 范围最大的通道（0=R，1=G，2=B）及其范围
+func (b box) widestChannel() (int, int) {
+	lo := [3]uint8{255, 255, 255}
+	hi := [3]uint8{}
+	for _, c := range b {
+		for ch, v := range [3]uint8{c.R, c.G, c.B} {
+			lo[ch] = min(lo[ch], v)
+			hi[ch] = max(hi[ch], v)
+		}
+	}
+	channel, spread := 0, -1
+	for ch := 0; ch < 3; ch++ {
+		if s := int(hi[ch]) - int(lo[ch]); s > spread {
+			channel, spread = ch, s
+		}
+	}
+	return channel, spread
+}
+
+// split 按范围最大的通道在中位数处切分为两个区域
+func (b box) split() (box, box) {
+	channel, _ := b.widestChannel()
+	value := func(c color.RGBA) uint8 {
+		switch channel {
+		case 0:
+			return c.R
+		case 1:
+			return c.G
+		}
+		return c.B
+	}
+	sort.Slice(b, func(i, j int) bool { return value(b[i]) < value(b[j]) })
+	mid := len(b) / 2
+	return b[:mid:mid], b[mid:]
+}
+
+// mean 区域的平均颜色
+func (b box) mean() color.RGBA {
+	var r, g, bl int
+	for _, c := range b {
+		r += int(c.R)
+		g += int(c.G)
+		bl += int(c.B)
+	}
+	n := len(b)
+	return color.RGBA{R: uint8(r / n), G: uint8(g / n), B: uint8(bl / n), A: 0xff}
+}
+
+// weightedMean 按像素数加权的平均颜色
+func weightedMean(a color.RGBA, na int, b color.RGBA, nb int) color.RGBA {
+	n := na + nb
+	mix := func(x, y uint8) uint8 { return uint8((int(x)*na + int(y)*nb) / n) }
+	return color.RGBA{R: mix(a.R, b.R), G: mix(a.G, b.G), B: mix(a.B, b.B), A: 0xff}
+}
+
+func round2(v float64) float64 {
+	return math.Round(v*100) / 100
+}
@@ -0,0 +1,113 @@
+package imaging
+
+import (
+	"bytes"
+	"encoding/base64"
+	"image"
+	"image/jpeg"
+	"math"
+	"strings"
+)
+
+const (
+	// blurHashX、blurHashY BlurHash 横向和纵向的分量数，4x3 适合 16:9 的壁纸
+	blurHashX = 4
+	blurHashY = 3
+	// blurHashSample 计算 BlurHash 前缩小到的尺寸，分量很少，更大的图片不会改变结果
+	blurHashSample = 64
+	// previewSize 预览图的最大边长
+	previewSize = 32
+	// previewQuality 预览图的 JPEG 质量，放大模糊显示时看不出差别
+	previewQuality = 50
+)
+
+// base83Chars BlurHash 使用的 base83 字符表
+const base83Chars = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz#$%*+,-.:;=?@[]^_{|}~"
+
+// BlurHash 计算图片的 BlurHash（https://blurha.sh），前端解码后得到模糊的占位图
+func BlurHash(img image.Image) string {
+	small := Fit(img, blurHashSample, blurHashSample)
+	width, height := small.Rect.Dx(), small.Rect.Dy()
+	if width == 0 || height == 0 {
+		return ""
+	}
+
+	// 每个分量是线性 RGB 与二维余弦基的内积
+	factors := make([][3]float64, 0, blurHashX*blurHashY)
+	for j := 0; j < blurHashY; j++ {
+		for i := 0; i < blurHashX; i++ {
+			normalisation := 2.0
+			if i == 0 && j == 0 {
+				normalisation = 1
+			}
+			var factor [3]float64
+			for y := 0; y < height; y++ {
+				cy := math.Cos(math.Pi * float64(j) * float64(y) / float64(height))
+				for x := 0; x < width; x++ {
+					basis := normalisation * math.Cos(math.Pi*float64(i)*float64(x)/float64(width)) * cy
+					p := small.Pix[(y*width+x)*4:]
+					factor[0] += basis * linear(p[0])
+					factor[1] += basis * linear(p[1])
+					factor[2] += basis * linear(p[2])
+				}
+			}
+			scale := 1 / float64(width*height)
+			factors = append(factors, [3]float64{factor[0] * scale, factor[1] * scale, factor[2] * scale})
+		}
+	}
+
+	var hash strings.Builder
+	encode83(&hash, (blurHashX-1)+(blurHashY-1)*9, 1)
+
+	dc, ac := factors[0], factors[1:]
+	maximum := 0.0
+	for _, factor := range ac {
+		for _, v := range factor {
+			maximum = math.Max(maximum, math.Abs(v))
+		}
+	}
+	quantisedMax := int(math.Max(0, math.Min(82, math.Floor(maximum*166-0.5))))
+	maximumValue := float64(quantisedMax+1) / 166
+	encode83(&hash, quantisedMax, 1)
+
+	encode83(&hash, srgb(dc[0])<<16|srgb(dc[1])<<8|srgb(dc[2]), 4)
+	for _, factor := range ac {
+		quant := func(v float64) int {
+			return int(math.Max(0, math.Min(18, math.Floor(signPow(v/maximumValue, 0.5)*9+9.5))))
+		}
+		encode83(&hash, quant(factor[0])*19*19+quant(factor[1])*19+quant(factor[2]), 2)
+	}
+	return hash.String()
+}
+
+// Preview 生成最大边长 32 像素的 JPEG 预览图，格式为 data URI，可直接用作 img 的 src
+func Preview(img image.Image) string {
+	small := Fit(img, previewSize, previewSize)
+	var buf bytes.Buffer
+	// 写入内存不会失败，只有空图片会出错
+	if err := jpeg.Encode(&buf, small, &jpeg.Options{Quality: previewQuality}); err != nil {
+		return ""
+	}
+	return "data:image/jpeg;base64," + base64.StdEncoding.EncodeToString(buf.Bytes())
+}
+
+// encode83 把 value 编码为 length 位 base83
+func encode83(b *strings.Builder, value, length int) {
+	for i := length - 1; i >= 0; i-- {
+		digit := value / int(math.Pow(83, float64(i))) % 83
+		b.WriteByte(base83Chars[digit])
+	}
+}
+
+// srgb 线性值转换为 8 位 sRGB 分量，与 linear 互逆
+func srgb(v float64) int {
+	v = math.Max(0, math.Min(1, v))
+	if v <= 0.0031308 {
+		return int(v*12.92*255 + 0.5)
+	}
+	return int((1.055*math.Pow(v, 1/2.4)-0.055)*255 + 0.5)
+}
+
+func signPow(v, exp float64) float64 {
+	return math.Copysign(math.Pow(math.Abs(v), exp), v)
+}
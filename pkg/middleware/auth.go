@@ -1,6 +1,8 @@
 package middleware
 
 import (
+	"net/http"
+
 	"github.com/gclm/galaxy-bing-wallpapers/pkg/config"
 	"github.com/gclm/galaxy-bing-wallpapers/pkg/handler"
 	"github.com/gin-gonic/gin"
@@ -11,20 +13,12 @@ func TokenAuth() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		token := c.GetHeader("Authorization")
 		if token == "" {
-			c.JSON(401, handler.ErrorResponse{
-				Code:    401,
-				Message: "Authorization token is required",
-			})
-			c.Abort()
+			handler.HandleError(c, handler.NewError(http.StatusUnauthorized, handler.CodeTokenRequired, ""))
 			return
 		}
 
 		if token != config.GlobalConfig.APIToken {
-			c.JSON(403, handler.ErrorResponse{
-				Code:    403,
-				Message: "Invalid authorization token",
-			})
-			c.Abort()
+			handler.HandleError(c, handler.NewError(http.StatusForbidden, handler.CodeTokenInvalid, ""))
 			return
 		}
 
@@ -1,18 +1,181 @@
 package middleware
 
-import "github.com/gin-gonic/gin"
+import (
+	"log"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
 
+	"github.com/gclm/galaxy-bing-wallpapers/pkg/config"
+	"github.com/gin-gonic/gin"
+)
+
+const regexOriginPrefix = "regex:"
+
+// CorsOptions 跨域策略配置
+type CorsOptions struct {
+	AllowedOrigins   []string      // 精确来源、* 、*.example.com 通配子域名或 regex: 前缀的正则（需完整匹配来源）
+	AllowedMethods   []string      // 允许的请求方法
+	AllowedHeaders   []string      // 允许的请求头
+	ExposedHeaders   []string      // 暴露给浏览器的响应头
+	AllowCredentials bool          // 是否允许携带凭证
+	MaxAge           time.Duration // 预检请求缓存时间
+}
+
+// CorsMiddleware 按全局配置创建跨域中间件
 func CorsMiddleware() gin.HandlerFunc {
+	cfg := config.GlobalConfig
+	return NewCors(CorsOptions{
+		AllowedOrigins:   cfg.CORSAllowedOrigins,
+		AllowedMethods:   []string{http.MethodGet, http.MethodOptions},
+		AllowedHeaders:   cfg.CORSAllowedHeaders,
+		ExposedHeaders:   cfg.CORSExposedHeaders,
+		AllowCredentials: cfg.CORSAllowCredentials,
+		MaxAge:           cfg.CORSMaxAge,
+	})
+}
+
+// NewCors 创建跨域中间件
+func NewCors(opts CorsOptions) gin.HandlerFunc {
+	matcher := newOriginMatcher(opts.AllowedOrigins)
+	allowMethods := strings.Join(opts.AllowedMethods, ", ")
+	allowHeaders := strings.Join(opts.AllowedHeaders, ", ")
+	exposeHeaders := strings.Join(opts.ExposedHeaders, ", ")
+	maxAge := strconv.Itoa(int(opts.MaxAge.Seconds()))
+
 	return func(c *gin.Context) {
-		c.Writer.Header().Set("Access-Control-Allow-Origin", "*")
-		c.Writer.Header().Set("Access-Control-Allow-Methods", "GET, OPTIONS")
-		c.Writer.Header().Set("Access-Control-Allow-Headers", "Content-Type")
+		origin := c.GetHeader("Origin")
+		preflight := c.Request.Method == http.MethodOptions &&
+			c.GetHeader("Access-Control-Request-Method") != ""
+
+		// 非跨域请求直接放行
+		if origin == "" {
+			if c.Request.Method == http.MethodOptions {
+				c.AbortWithStatus(http.StatusNoContent)
+				return
+			}
+			c.Next()
+			return
+		}
+
+		header := c.Writer.Header()
+		header.Add("Vary", "Origin")
 
-		if c.Request.Method == "OPTIONS" {
-			c.AbortWithStatus(204)
+		if !matcher.match(origin) {
+			// 不在允许列表中的来源不返回任何 CORS 头，由浏览器拦截
+			if preflight {
+				c.AbortWithStatus(http.StatusForbidden)
+				return
+			}
+			c.Next()
 			return
 		}
 
+		// 携带凭证时不能使用 *，需回显具体来源
+		if matcher.any && !opts.AllowCredentials {
+			header.Set("Access-Control-Allow-Origin", "*")
+		} else {
+			header.Set("Access-Control-Allow-Origin", origin)
+		}
+		if opts.AllowCredentials {
+			header.Set("Access-Control-Allow-Credentials", "true")
+		}
+
+		if preflight {
+			header.Add("Vary", "Access-Control-Request-Method")
+			header.Add("Vary", "Access-Control-Request-Headers")
+			header.Set("Access-Control-Allow-Methods", allowMethods)
+			if allowHeaders != "" {
+				header.Set("Access-Control-Allow-Headers", allowHeaders)
+			}
+			if opts.MaxAge > 0 {
+				header.Set("Access-Control-Max-Age", maxAge)
+			}
+			c.AbortWithStatus(http.StatusNoContent)
+			return
+		}
+
+		if exposeHeaders != "" {
+			header.Set("Access-Control-Expose-Headers", exposeHeaders)
+		}
+
 		c.Next()
 	}
 }
+
+// originMatcher 来源匹配器
+type originMatcher struct {
+	any      bool
+	exact    map[string]bool
+	suffixes []wildcardOrigin
+	patterns []*regexp.Regexp
+}
+
+// wildcardOrigin 通配子域名来源，如 https://*.example.com
+type wildcardOrigin struct {
+	scheme string // 为空时不限制协议
+	suffix string // 形如 .example.com
+}
+
+func newOriginMatcher(origins []string) *originMatcher {
+	m := &originMatcher{exact: make(map[string]bool)}
+
+	for _, origin := range origins {
+		switch {
+		case origin == "*":
+			m.any = true
+		case strings.HasPrefix(origin, regexOriginPrefix):
+			// 整个来源都必须匹配，否则 https://.*\.example\.com 也会放行 https://evil.example.com.attacker.io
+			re, err := regexp.Compile(`^(?:` + strings.TrimPrefix(origin, regexOriginPrefix) + `)$`)
+			if err != nil {
+				log.Printf("Warning: invalid CORS origin pattern %q: %v", origin, err)
+				continue
+			}
+			m.patterns = append(m.patterns, re)
+		case strings.Contains(origin, "*."):
+			scheme, host, found := strings.Cut(origin, "://")
+			if !found {
+				scheme, host = "", origin
+			}
+			m.suffixes = append(m.suffixes, wildcardOrigin{
+				scheme: strings.ToLower(scheme),
+				suffix: strings.ToLower(strings.TrimPrefix(host, "*")),
+			})
+		default:
+			m.exact[strings.ToLower(strings.TrimSuffix(origin, "/"))] = true
+		}
+	}
+
+	return m
+}
+
+func (m *originMatcher) match(origin string) bool {
+	if m.any {
+		return true
+	}
+
+	lower := strings.ToLower(origin)
+	if m.exact[lower] {
+		return true
+	}
+
+	scheme, host, _ := strings.Cut(lower, "://")
+	for _, w := range m.suffixes {
+		if w.scheme != "" && w.scheme != scheme {
+			continue
+		}
+		if strings.HasSuffix(host, w.suffix) && len(host) > len(w.suffix) {
+			return true
+		}
+	}
+
+	for _, re := range m.patterns {
+		if re.MatchString(origin) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,68 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestOriginMatcher(t *testing.T) {
+	m := newOriginMatcher([]string{
+		"https://wall.example.com",
+		"https://*.example.org",
+		`regex:https://.*\.example\.com`,
+		`regex:^https://[a-z0-9-]+\.vercel\.app$`,
+	})
+
+	tests := []struct {
+		origin string
+		want   bool
+	}{
+		{"https://wall.example.com", true},
+		{"https://WALL.example.com", true},
+		{"https://a.example.org", true},
+		{"http://a.example.org", false},
+		{"https://example.org", false},
+		{"https://evil.example.com", true},
+		{"https://preview-1.vercel.app", true},
+		// 后缀攻击：允许的来源后面接上攻击者的域名
+		{"https://evil.example.com.attacker.io", false},
+		{"https://preview-1.vercel.app.attacker.io", false},
+		// 前缀攻击：攻击者的来源中包含允许的来源
+		{"evil-https://evil.example.com", false},
+		{"http://https://a.vercel.app", false},
+		{"https://attacker.io", false},
+	}
+	for _, tt := range tests {
+		if got := m.match(tt.origin); got != tt.want {
+			t.Errorf("match(%q) = %v, want %v", tt.origin, got, tt.want)
+		}
+	}
+}
+
+func TestCorsRejectsUnanchoredRegexMatch(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(NewCors(CorsOptions{
+		AllowedOrigins:   []string{`regex:https://.*\.example\.com`},
+		AllowedMethods:   []string{http.MethodGet},
+		AllowCredentials: true,
+	}))
+	r.GET("/", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	for origin, want := range map[string]string{
+		"https://app.example.com":             "https://app.example.com",
+		"https://app.example.com.attacker.io": "",
+		"evil-https://app.example.com":        "",
+	} {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("Origin", origin)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		if got := w.Header().Get("Access-Control-Allow-Origin"); got != want {
+			t.Errorf("origin %q: Access-Control-Allow-Origin = %q, want %q", origin, got, want)
+		}
+	}
+}
@@ -1,6 +1,9 @@
 package middleware
 
 import (
+	"fmt"
+	"net/http"
+
 	"github.com/gclm/galaxy-bing-wallpapers/pkg/handler"
 	"github.com/gin-gonic/gin"
 )
@@ -8,9 +11,10 @@ import (
 // Recovery 恢复中间件
 func Recovery() gin.HandlerFunc {
 	return gin.CustomRecovery(func(c *gin.Context, recovered interface{}) {
-		c.JSON(500, handler.ErrorResponse{
-			Code:    500,
-			Message: "Internal server error",
+		handler.HandleError(c, &handler.APIError{
+			Status: http.StatusInternalServerError,
+			Code:   handler.CodeInternalError,
+			Err:    fmt.Errorf("panic recovered: %v", recovered),
 		})
 	})
 }
@@ -0,0 +1,190 @@
+package migrate
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"time"
+
+	"github.com/gclm/galaxy-bing-wallpapers/pkg/database"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// collectionName 记录已执行迁移的集合
+const collectionName = "schema_migrations"
+
+// Migration 单个数据库迁移，按 Version 从小到大执行
+type Migration struct {
+	Version int
+	Name    string
+	Up      func(ctx context.Context) error
+	Down    func(ctx context.Context) error
+}
+
+// Record schema_migrations 中的一条记录
+type Record struct {
+	Version   int       `bson:"version" json:"version"`
+	Name      string    `bson:"name" json:"name"`
+	AppliedAt time.Time `bson:"applied_at" json:"applied_at"`
+}
+
+// State 迁移的执行状态
+type State struct {
+	Version   int        `json:"version"`
+	Name      string     `json:"name"`
+	Applied   bool       `json:"applied"`
+	AppliedAt *time.Time `json:"applied_at,omitempty"`
+	Unknown   bool       `json:"unknown,omitempty"` // 数据库中有记录但代码中不存在
+}
+
+func init() {
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+	for i, m := range migrations {
+		if m.Version <= 0 || m.Up == nil || m.Down == nil {
+			panic(fmt.Sprintf("migrate: invalid migration %d %s", m.Version, m.Name))
+		}
+		if i > 0 && migrations[i-1].Version == m.Version {
+			panic(fmt.Sprintf("migrate: duplicate migration version %d", m.Version))
+		}
+	}
+}
+
+// All 返回所有迁移，按版本升序
+func All() []Migration {
+	return append([]Migration(nil), migrations...)
+}
+
+// Latest 返回最新的迁移版本
+func Latest() int {
+	if len(migrations) == 0 {
+		return 0
+	}
+	return migrations[len(migrations)-1].Version
+}
+
+// Status 返回每个迁移的执行状态
+func Status(ctx context.Context) ([]State, error) {
+	applied, err := appliedRecords(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var states []State
+	for _, m := range migrations {
+		state := State{Version: m.Version, Name: m.Name}
+		if record, ok := applied[m.Version]; ok {
+			appliedAt := record.AppliedAt
+			state.Applied = true
+			state.AppliedAt = &appliedAt
+			delete(applied, m.Version)
+		}
+		states = append(states, state)
+	}
+	for _, record := range applied {
+		appliedAt := record.AppliedAt
+		states = append(states, State{Version: record.Version, Name: record.Name, Applied: true, AppliedAt: &appliedAt, Unknown: true})
+	}
+
+	sort.Slice(states, func(i, j int) bool { return states[i].Version < states[j].Version })
+	return states, nil
+}
+
+// Up 按顺序执行版本不大于 target 的未执行迁移，target 为 0 时执行到最新版本
+func Up(ctx context.Context, target int) ([]Migration, error) {
+	if err := ensureCollection(ctx); err != nil {
+		return nil, err
+	}
+	applied, err := appliedRecords(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var done []Migration
+	for _, m := range migrations {
+		if target > 0 && m.Version > target {
+			break
+		}
+		if _, ok := applied[m.Version]; ok {
+			continue
+		}
+
+		log.Printf("Applying migration %d_%s", m.Version, m.Name)
+		if err := m.Up(ctx); err != nil {
+			return done, fmt.Errorf("migration %d_%s failed: %v", m.Version, m.Name, err)
+		}
+		record := Record{Version: m.Version, Name: m.Name, AppliedAt: time.Now()}
+		if _, err := database.GetCollection(collectionName).InsertOne(ctx, record); err != nil {
+			return done, fmt.Errorf("failed to record migration %d_%s: %v", m.Version, m.Name, err)
+		}
+		done = append(done, m)
+		// 迁移可能原地修改已有壁纸，让缓存的统计结果失效
+		if err := database.BumpDataVersion(ctx); err != nil {
+			return done, err
+		}
+	}
+	return done, nil
+}
+
+// Down 按倒序回滚最近执行的 steps 个迁移
+func Down(ctx context.Context, steps int) ([]Migration, error) {
+	applied, err := appliedRecords(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var done []Migration
+	for i := len(migrations) - 1; i >= 0 && len(done) < steps; i-- {
+		m := migrations[i]
+		if _, ok := applied[m.Version]; !ok {
+			continue
+		}
+
+		log.Printf("Reverting migration %d_%s", m.Version, m.Name)
+		if err := m.Down(ctx); err != nil {
+			return done, fmt.Errorf("rollback of migration %d_%s failed: %v", m.Version, m.Name, err)
+		}
+		if _, err := database.GetCollection(collectionName).DeleteOne(ctx, bson.M{"version": m.Version}); err != nil {
+			return done, fmt.Errorf("failed to remove migration record %d_%s: %v", m.Version, m.Name, err)
+		}
+		done = append(done, m)
+		if err := database.BumpDataVersion(ctx); err != nil {
+			return done, err
+		}
+	}
+	return done, nil
+}
+
+// ensureCollection 为迁移记录创建唯一索引，避免并发执行时重复记录同一版本
+func ensureCollection(ctx context.Context) error {
+	_, err := database.GetCollection(collectionName).Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "version", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create %s index: %v", collectionName, err)
+	}
+	return nil
+}
+
+// appliedRecords 读取已执行的迁移，按版本索引
+func appliedRecords(ctx context.Context) (map[int]Record, error) {
+	cursor, err := database.GetCollection(collectionName).Find(ctx, bson.M{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %v", collectionName, err)
+	}
+	defer cursor.Close(ctx)
+
+	var records []Record
+	if err := cursor.All(ctx, &records); err != nil {
+		return nil, fmt.Errorf("failed to decode %s: %v", collectionName, err)
+	}
+
+	applied := make(map[int]Record, len(records))
+	for _, record := range records {
+		applied[record.Version] = record
+	}
+	return applied, nil
+}
@@ -0,0 +1,310 @@
+package migrate
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/gclm/galaxy-bing-wallpapers/pkg/copyright"
+	"github.com/gclm/galaxy-bing-wallpapers/pkg/database"
+	"github.com/gclm/galaxy-bing-wallpapers/pkg/geo"
+	"github.com/gclm/galaxy-bing-wallpapers/pkg/model"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// migrations 所有迁移，新增迁移时追加到末尾并使用递增的版本号
+var migrations = []Migration{
+	{
+		Version: 1,
+		Name:    "create_wallpaper_indexes",
+		Up:      createWallpaperIndexes,
+		Down:    dropWallpaperIndexes,
+	},
+	{
+		Version: 2,
+		Name:    "create_random_history_indexes",
+		Up:      createRandomHistoryIndexes,
+		Down:    dropRandomHistoryIndexes,
+	},
+	{
+		Version: 3,
+		Name:    "backfill_credit",
+		Up:      backfillCredit,
+		Down:    removeCredit,
+	},
+	{
+		Version: 4,
+		Name:    "backfill_geo",
+		Up:      backfillGeo,
+		Down:    removeGeo,
+	},
+	{
+		Version: 5,
+		Name:    "backfill_ohr",
+		Up:      backfillOHR,
+		Down:    removeOHR,
+	},
+	{
+		Version: 6,
+		Name:    "create_palette_index",
+		Up:      createPaletteIndex,
+		Down:    dropPaletteIndex,
+	},
+	{
+		Version: 7,
+		Name:    "reparse_credit_geo",
+		Up:      reparseCreditGeo,
+		Down:    keepCreditGeo,
+	},
+}
+
+// randomHistoryTTL 随机接口返回历史的保留时间，不重复模式的时间窗口不能超过它
+const randomHistoryTTL = 30 * 24 * time.Hour
+
+// createWallpaperIndexes 创建 id 唯一索引和 datetime+mkt 唯一复合索引
+func createWallpaperIndexes(ctx context.Context) error {
+	collection := database.GetCollection("wallpapers")
+
+	// 创建ID唯一索引
+	_, err := collection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "id", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create id index: %v", err)
+	}
+
+	// 创建日期和市场代码复合索引
+	_, err = collection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{
+			{Key: "datetime", Value: 1},
+			{Key: "mkt", Value: 1},
+		},
+		Options: options.Index().SetUnique(true),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create datetime-mkt index: %v", err)
+	}
+
+	return nil
+}
+
+func dropWallpaperIndexes(ctx context.Context) error {
+	return dropIndexes(ctx, "wallpapers", "id_1", "datetime_1_mkt_1")
+}
+
+// createRandomHistoryIndexes 为随机接口返回历史创建查询索引和过期索引
+func createRandomHistoryIndexes(ctx context.Context) error {
+	_, err := database.GetCollection(database.RandomHistoryCollection).Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys: bson.D{{Key: "client", Value: 1}, {Key: "served_at", Value: -1}},
+		},
+		{
+			Keys:    bson.D{{Key: "served_at", Value: 1}},
+			Options: options.Index().SetExpireAfterSeconds(int32(randomHistoryTTL.Seconds())),
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create random history indexes: %v", err)
+	}
+	return nil
+}
+
+func dropRandomHistoryIndexes(ctx context.Context) error {
+	return dropIndexes(ctx, database.RandomHistoryCollection, "client_1_served_at_-1", "served_at_1")
+}
+
+// backfillBatchSize 回填字段时每批写入的记录数
+const backfillBatchSize = 500
+
+// backfillWallpapers 遍历全部壁纸（只读取 projection 中的字段），按 update 返回的更新语句分批写回
+func backfillWallpapers(ctx context.Context, projection bson.M, update func(model.Wallpaper) bson.M) error {
+	collection := database.GetCollection("wallpapers")
+
+	cursor, err := collection.Find(ctx, bson.M{}, options.Find().SetProjection(projection))
+	if err != nil {
+		return fmt.Errorf("failed to query wallpapers: %v", err)
+	}
+	defer cursor.Close(ctx)
+
+	flush := func(models []mongo.WriteModel) error {
+		if len(models) == 0 {
+			return nil
+		}
+		if _, err := collection.BulkWrite(ctx, models, options.BulkWrite().SetOrdered(false)); err != nil {
+			return fmt.Errorf("failed to update wallpapers: %v", err)
+		}
+		return nil
+	}
+
+	models := make([]mongo.WriteModel, 0, backfillBatchSize)
+	for cursor.Next(ctx) {
+		var wallpaper model.Wallpaper
+		if err := cursor.Decode(&wallpaper); err != nil {
+			return fmt.Errorf("failed to decode wallpaper: %v", err)
+		}
+		models = append(models, mongo.NewUpdateOneModel().
+			SetFilter(bson.M{"id": wallpaper.ID}).
+			SetUpdate(update(wallpaper)))
+		if len(models) == backfillBatchSize {
+			if err := flush(models); err != nil {
+				return err
+			}
+			models = models[:0]
+		}
+	}
+	if err := cursor.Err(); err != nil {
+		return fmt.Errorf("failed to iterate wallpapers: %v", err)
+	}
+	return flush(models)
+}
+
+// backfillCredit 解析已有壁纸的版权信息写入 credit 字段，并为署名过滤创建索引
+func backfillCredit(ctx context.Context) error {
+	err := backfillWallpapers(ctx, bson.M{"id": 1, "copyright": 1, "mkt": 1}, func(wallpaper model.Wallpaper) bson.M {
+		return bson.M{"$set": bson.M{"credit": copyright.Parse(wallpaper.Copyright, wallpaper.Mkt)}}
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = database.GetCollection("wallpapers").Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "credit.photographer", Value: 1}}},
+		{Keys: bson.D{{Key: "credit.agency", Value: 1}}},
+		{Keys: bson.D{{Key: "credit.country_code", Value: 1}}},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create credit indexes: %v", err)
+	}
+	return nil
+}
+
+func removeCredit(ctx context.Context) error {
+	if err := dropIndexes(ctx, "wallpapers", "credit.photographer_1", "credit.agency_1", "credit.country_code_1"); err != nil {
+		return err
+	}
+	if _, err := database.UpdateWallpapers(ctx, bson.M{}, bson.M{"$unset": bson.M{"credit": ""}}); err != nil {
+		return err
+	}
+	return nil
+}
+
+// backfillGeo 按已有壁纸的署名信息标注坐标，并为按范围查询创建索引
+// 依赖版本 3 回填的 credit 字段；无法定位的壁纸删除 geo 字段
+func backfillGeo(ctx context.Context) error {
+	err := backfillWallpapers(ctx, bson.M{"id": 1, "credit": 1}, func(wallpaper model.Wallpaper) bson.M {
+		if wallpaper.Credit != nil {
+			if g := geo.Locate(*wallpaper.Credit); g != nil {
+				return bson.M{"$set": bson.M{"geo": g}}
+			}
+		}
+		return bson.M{"$unset": bson.M{"geo": ""}}
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = database.GetCollection("wallpapers").Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "geo.lat", Value: 1}, {Key: "geo.lon", Value: 1}},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create geo index: %v", err)
+	}
+	return nil
+}
+
+func removeGeo(ctx context.Context) error {
+	if err := dropIndexes(ctx, "wallpapers", "geo.lat_1_geo.lon_1"); err != nil {
+		return err
+	}
+	if _, err := database.UpdateWallpapers(ctx, bson.M{}, bson.M{"$unset": bson.M{"geo": ""}}); err != nil {
+		return err
+	}
+	return nil
+}
+
+// backfillOHR 从已有壁纸的 URL 提取图片标识，并为按图片查询各市场版本创建索引
+func backfillOHR(ctx context.Context) error {
+	err := backfillWallpapers(ctx, bson.M{"id": 1, "url": 1}, func(wallpaper model.Wallpaper) bson.M {
+		if ohr := model.ParseOHR(wallpaper.Url); ohr != "" {
+			return bson.M{"$set": bson.M{"ohr": ohr}}
+		}
+		return bson.M{"$unset": bson.M{"ohr": ""}}
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = database.GetCollection("wallpapers").Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "ohr", Value: 1}, {Key: "datetime", Value: 1}},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create ohr index: %v", err)
+	}
+	return nil
+}
+
+func removeOHR(ctx context.Context) error {
+	if err := dropIndexes(ctx, "wallpapers", "ohr_1_datetime_1"); err != nil {
+		return err
+	}
+	if _, err := database.UpdateWallpapers(ctx, bson.M{}, bson.M{"$unset": bson.M{"ohr": ""}}); err != nil {
+		return err
+	}
+	return nil
+}
+
+// createPaletteIndex 为按颜色检索调色板创建索引
+// 调色板需要下载图片计算，由抓取程序和 cmd/backfill 写入，迁移只创建索引
+func createPaletteIndex(ctx context.Context) error {
+	_, err := database.GetCollection("wallpapers").Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "palette.l", Value: 1}, {Key: "palette.a", Value: 1}, {Key: "palette.b", Value: 1}},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create palette index: %v", err)
+	}
+	return nil
+}
+
+func dropPaletteIndex(ctx context.Context) error {
+	return dropIndexes(ctx, "wallpapers", "palette.l_1_palette.a_1_palette.b_1")
+}
+
+// reparseCreditGeo 用修正后的解析规则重新解析版权信息并重新定位
+// 版本 3、4 回填时会把其他语言的国家名称误认为国家（如德语 Island 把 Long Island 识别为冰岛），
+// 之后写入的壁纸也是按旧规则解析的，因此对全部壁纸重新计算 credit 和 geo
+func reparseCreditGeo(ctx context.Context) error {
+	return backfillWallpapers(ctx, bson.M{"id": 1, "copyright": 1, "mkt": 1}, func(wallpaper model.Wallpaper) bson.M {
+		credit := copyright.Parse(wallpaper.Copyright, wallpaper.Mkt)
+		if g := geo.Locate(credit); g != nil {
+			return bson.M{"$set": bson.M{"credit": credit, "geo": g}}
+		}
+		return bson.M{"$set": bson.M{"credit": credit}, "$unset": bson.M{"geo": ""}}
+	})
+}
+
+// keepCreditGeo 旧的解析结果是错误的，回滚时保留重新解析后的数据
+func keepCreditGeo(ctx context.Context) error {
+	return nil
+}
+
+// dropIndexes 删除指定名称的索引，索引不存在时忽略
+func dropIndexes(ctx context.Context, collectionName string, names ...string) error {
+	indexes := database.GetCollection(collectionName).Indexes()
+	for _, name := range names {
+		if _, err := indexes.DropOne(ctx, name); err != nil && !isIndexNotFound(err) {
+			return fmt.Errorf("failed to drop index %s: %v", name, err)
+		}
+	}
+	return nil
+}
+
+// isIndexNotFound 索引不存在的错误（IndexNotFound，错误码 27）
+func isIndexNotFound(err error) bool {
+	if cmdErr, ok := err.(mongo.CommandError); ok {
+		return cmdErr.Code == 27
+	}
+	return false
+}
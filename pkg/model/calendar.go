@@ -0,0 +1,34 @@
+package model
+
+// CalendarDay 日历中的一天，没有壁纸时 Missing 为 true，其余字段为空
+type CalendarDay struct {
+	Date      string `json:"date"`                // 日期 YYYY-MM-DD
+	Missing   bool   `json:"missing"`             // 当天没有壁纸
+	ID        int    `json:"id,omitempty"`        // 壁纸 ID
+	Title     string `json:"title,omitempty"`     // 图片标题
+	Url       string `json:"url,omitempty"`       // 原始图片URL
+	Thumbnail string `json:"thumbnail,omitempty"` // 缩略图URL
+	OHR       string `json:"ohr,omitempty"`       // 图片标识
+}
+
+// ArchiveCount 某市场某月的壁纸数量
+type ArchiveCount struct {
+	Year  int    `bson:"year" json:"year"`
+	Month int    `bson:"month" json:"month"`
+	Mkt   string `bson:"mkt" json:"mkt"`
+	Count int64  `bson:"count" json:"count"`
+}
+
+// ArchiveYear 归档中的一年
+type ArchiveYear struct {
+	Year   int            `json:"year"`
+	Count  int64          `json:"count"`  // 全年壁纸数量
+	Months []ArchiveMonth `json:"months"` // 有壁纸的月份，按月份倒序
+}
+
+// ArchiveMonth 归档中的一个月
+type ArchiveMonth struct {
+	Month   int              `json:"month"`
+	Count   int64            `json:"count"`   // 当月壁纸数量
+	Markets map[string]int64 `json:"markets"` // 各市场的数量
+}
@@ -0,0 +1,12 @@
+package model
+
+import "time"
+
+// FetchLog 壁纸抓取记录，每个市场每次抓取一条
+type FetchLog struct {
+	Mkt       string    `bson:"mkt" json:"mkt"`                         // 市场代码
+	Success   bool      `bson:"success" json:"success"`                 // 是否成功
+	IsNew     bool      `bson:"is_new" json:"is_new"`                   // 是否保存了新壁纸
+	Error     string    `bson:"error,omitempty" json:"error,omitempty"` // 失败原因
+	FetchedAt time.Time `bson:"fetched_at" json:"fetched_at"`           // 抓取时间
+}
@@ -0,0 +1,30 @@
+package model
+
+// Geo 按版权信息中的地点标注的国家和近似坐标
+type Geo struct {
+	CountryCode string  `bson:"country_code" json:"country_code"`       // ISO 3166-1 国家代码
+	Place       string  `bson:"place,omitempty" json:"place,omitempty"` // 匹配到的地区或地点，如 California；只定位到国家时为空
+	Precision   string  `bson:"precision" json:"precision"`             // 定位精度：place、region 或 country
+	Lat         float64 `bson:"lat" json:"lat"`                         // 纬度
+	Lon         float64 `bson:"lon" json:"lon"`                         // 经度
+}
+
+// FeatureCollection GeoJSON 要素集合（RFC 7946）
+type FeatureCollection struct {
+	Type     string    `json:"type"` // 固定为 FeatureCollection
+	Features []Feature `json:"features"`
+}
+
+// Feature GeoJSON 要素
+type Feature struct {
+	Type       string                 `json:"type"` // 固定为 Feature
+	ID         int                    `json:"id"`
+	Geometry   Point                  `json:"geometry"`
+	Properties map[string]interface{} `json:"properties"`
+}
+
+// Point GeoJSON 点，坐标顺序为 [经度, 纬度]
+type Point struct {
+	Type        string     `json:"type"` // 固定为 Point
+	Coordinates [2]float64 `json:"coordinates"`
+}
@@ -0,0 +1,82 @@
+package model
+
+import (
+	"time"
+
+	// 内嵌时区数据库，Vercel 等精简运行环境中可能没有系统时区文件
+	_ "time/tzdata"
+)
+
+// Markets 支持的市场代码
+var Markets = []string{
+	"zh-CN", // 中国
+	"de-DE", // 德国
+	"en-CA", // 加拿大（英语）
+	"en-GB", // 英国
+	"en-IN", // 印度
+	"en-US", // 美国
+	"fr-FR", // 法国
+	"it-IT", // 意大利
+	"ja-JP", // 日本
+}
+
+// MarketInfo 市场信息
+type MarketInfo struct {
+	Code     string `json:"code"`     // 市场代码
+	Name     string `json:"name"`     // 市场名称
+	Timezone string `json:"timezone"` // 市场所在时区，必应按此时区切换每日壁纸
+}
+
+var marketNames = map[string]string{
+	"zh-CN": "中国",
+	"de-DE": "德国",
+	"en-CA": "加拿大（英语）",
+	"en-GB": "英国",
+	"en-IN": "印度",
+	"en-US": "美国",
+	"fr-FR": "法国",
+	"it-IT": "意大利",
+	"ja-JP": "日本",
+}
+
+// marketTimezones 各市场的 IANA 时区
+var marketTimezones = map[string]string{
+	"zh-CN": "Asia/Shanghai",
+	"de-DE": "Europe/Berlin",
+	"en-CA": "America/Toronto",
+	"en-GB": "Europe/London",
+	"en-IN": "Asia/Kolkata",
+	"en-US": "America/Los_Angeles",
+	"fr-FR": "Europe/Paris",
+	"it-IT": "Europe/Rome",
+	"ja-JP": "Asia/Tokyo",
+}
+
+// MarketInfos 获取所有支持市场的信息
+func MarketInfos() []MarketInfo {
+	infos := make([]MarketInfo, 0, len(Markets))
+	for _, mkt := range Markets {
+		infos = append(infos, MarketInfo{Code: mkt, Name: marketNames[mkt], Timezone: marketTimezones[mkt]})
+	}
+	return infos
+}
+
+// IsValidMarket 判断是否为支持的市场代码
+func IsValidMarket(mkt string) bool {
+	for _, m := range Markets {
+		if m == mkt {
+			return true
+		}
+	}
+	return false
+}
+
+// MarketLocation 获取市场所在时区，未知市场返回 UTC
+func MarketLocation(mkt string) *time.Location {
+	if name, ok := marketTimezones[mkt]; ok {
+		if loc, err := time.LoadLocation(name); err == nil {
+			return loc
+		}
+	}
+	return time.UTC
+}
@@ -0,0 +1,11 @@
+package model
+
+import "time"
+
+// RandomPick 随机接口返回给某个客户端的壁纸，用于不重复模式
+type RandomPick struct {
+	Client      string    `bson:"client" json:"client"`               // 客户端标识
+	WallpaperID int       `bson:"wallpaper_id" json:"wallpaper_id"`   // 壁纸 ID
+	OHR         string    `bson:"ohr,omitempty" json:"ohr,omitempty"` // 图片标识，其他市场的同一张图片也视为已返回
+	ServedAt    time.Time `bson:"served_at" json:"served_at"`         // 返回时间
+}
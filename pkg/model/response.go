@@ -10,9 +10,19 @@ type WallpaperList struct {
 
 // ImageResponse 图片信息响应结构
 type ImageResponse struct {
-	Url      string `json:"url"`      // 图片URL
-	Title    string `json:"title"`    // 图片标题
-	Datetime string `json:"datetime"` // 日期时间
+	Url      string   `json:"url"`                // 图片URL
+	Title    string   `json:"title"`              // 图片标题
+	Datetime string   `json:"datetime"`           // 日期时间
+	Color    string   `json:"color,omitempty"`    // 主色调
+	Palette  []Swatch `json:"palette,omitempty"`  // 调色板
+	BlurHash string   `json:"blurhash,omitempty"` // 模糊占位图
+	LQIP     string   `json:"lqip,omitempty"`     // 极小的 JPEG 预览图
+}
+
+// WallpaperItem 列表中的壁纸，附带各尺寸缩略图的地址
+type WallpaperItem struct {
+	Wallpaper
+	Thumbnails map[string]string `json:"thumbnails"` // 按尺寸索引，如 320x180
 }
 
 // 添加统一的API响应结构
@@ -22,3 +32,18 @@ type ApiResponse struct {
 	Data    interface{} `json:"data,omitempty"`  // 响应数据
 	Total   int64       `json:"total,omitempty"` // 总数（列表接口使用）
 }
+
+// ImageGroup 同一张图片（OHR 标识相同）在各市场的版本
+type ImageGroup struct {
+	OHR       string      `json:"ohr"`        // 图片标识
+	FirstSeen string      `json:"first_seen"` // 最早出现的日期
+	LastSeen  string      `json:"last_seen"`  // 最近出现的日期
+	Markets   []string    `json:"markets"`    // 出现过的市场
+	Variants  []Wallpaper `json:"variants"`   // 各市场的记录，按日期和市场排序
+}
+
+// SimilarWallpaper 相似的壁纸及其与源图片的汉明距离
+type SimilarWallpaper struct {
+	Distance  int       `json:"distance"`
+	Wallpaper Wallpaper `json:"wallpaper"`
+}
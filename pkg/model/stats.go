@@ -0,0 +1,54 @@
+package model
+
+import "time"
+
+// Stats 壁纸库的统计信息
+type Stats struct {
+	Total         int64         `json:"total"`         // 壁纸总数（各市场分别计数）
+	Markets       []MarketStats `json:"markets"`       // 各市场的数量和覆盖情况，按支持的市场顺序
+	Agencies      []NameCount   `json:"agencies"`      // 图片数量最多的图片库
+	Photographers []NameCount   `json:"photographers"` // 图片数量最多的摄影师
+	Reuse         ReuseStats    `json:"reuse"`         // 同一张图片在多个市场使用的情况
+	Fetch         []FetchStats  `json:"fetch"`         // 各市场的抓取成功率
+}
+
+// MarketStats 单个市场的壁纸统计
+type MarketStats struct {
+	Mkt      string  `bson:"_id" json:"mkt"`
+	Count    int64   `bson:"count" json:"count"`
+	First    string  `bson:"first" json:"first"` // 最早的日期
+	Last     string  `bson:"last" json:"last"`   // 最近的日期
+	Coverage float64 `bson:"-" json:"coverage"`  // 首尾日期之间有壁纸的天数占比（百分比）
+	GapDays  int     `bson:"-" json:"gap_days"`  // 首尾日期之间缺少壁纸的天数
+}
+
+// NameCount 名称及对应的图片数量
+type NameCount struct {
+	Name  string `bson:"_id" json:"name"`
+	Count int64  `bson:"count" json:"count"`
+}
+
+// ReuseStats 图片跨市场复用统计，按 OHR 图片标识归并
+type ReuseStats struct {
+	Images       int64         `json:"images"`       // 不同图片的数量
+	Shared       int64         `json:"shared"`       // 在两个及以上市场使用的图片数量
+	AvgMarkets   float64       `json:"avg_markets"`  // 每张图片平均使用的市场数
+	Distribution []ReuseBucket `json:"distribution"` // 按使用的市场数分布
+}
+
+// ReuseBucket 使用了 Markets 个市场的图片数量
+type ReuseBucket struct {
+	Markets int   `bson:"_id" json:"markets"`
+	Images  int64 `bson:"images" json:"images"`
+}
+
+// FetchStats 单个市场的抓取统计
+type FetchStats struct {
+	Mkt         string     `bson:"_id" json:"mkt"`
+	Attempts    int64      `bson:"attempts" json:"attempts"`                             // 抓取次数
+	Successes   int64      `bson:"successes" json:"successes"`                           // 成功次数
+	New         int64      `bson:"new" json:"new"`                                       // 保存了新壁纸的次数
+	SuccessRate float64    `bson:"-" json:"success_rate"`                                // 成功率（百分比）
+	LastSuccess *time.Time `bson:"last_success,omitempty" json:"last_success,omitempty"` // 最近一次成功的时间
+	LastFailure *time.Time `bson:"last_failure,omitempty" json:"last_failure,omitempty"` // 最近一次失败的时间
+}
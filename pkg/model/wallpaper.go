@@ -2,20 +2,63 @@ package model
 
 import (
 	"fmt"
+	"regexp"
 	"strings"
 )
 
+// resolutionSuffix 图片URL末尾的尺寸部分，如 _1920x1080.jpg、_UHD.jpg
+var resolutionSuffix = regexp.MustCompile(`_(\d+x\d+|UHD)\.jpg$`)
+
+// ohrPattern 图片 URL 中的 OHR 标识，如 OHR.BlueBelize_EN-US7787222240_1920x1080.jpg 中的 BlueBelize
+// 早期的镜像 URL 没有 OHR. 前缀，如 /bing/2018/BadlandsCycle_ZH-CN11688990875_1920x1080.jpg
+var ohrPattern = regexp.MustCompile(`OHR\.([A-Za-z0-9]+)_|/([A-Za-z0-9]+)_[A-Za-z]{2}-[A-Za-z]{2}\d*_`)
+
 // Wallpaper 必应壁纸数据结构
 type Wallpaper struct {
-	ID            int    `bson:"id" json:"id"`                       // 唯一标识
-	Title         string `bson:"title" json:"title"`                 // 图片标题
-	Url           string `bson:"url" json:"url"`                     // 图片URL
-	Datetime      string `bson:"datetime" json:"datetime"`           // 日期时间
-	Copyright     string `bson:"copyright" json:"copyright"`         // 版权信息
-	CopyrightLink string `bson:"copyrightlink" json:"copyrightlink"` // 版权链接
-	Hsh           string `bson:"hsh" json:"hsh"`                     // 哈希值
-	CreatedTime   string `bson:"created_time" json:"created_time"`   // 创建时间
-	Mkt           string `bson:"mkt" json:"mkt"`                     // 市场代码 如：fr-FR
+	ID            int          `bson:"id" json:"id"`                                 // 唯一标识
+	Title         string       `bson:"title" json:"title"`                           // 图片标题
+	Url           string       `bson:"url" json:"url"`                               // 图片URL
+	Datetime      string       `bson:"datetime" json:"datetime"`                     // 日期时间
+	Copyright     string       `bson:"copyright" json:"copyright"`                   // 版权信息
+	CopyrightLink string       `bson:"copyrightlink" json:"copyrightlink"`           // 版权链接
+	Hsh           string       `bson:"hsh" json:"hsh"`                               // 哈希值
+	CreatedTime   string       `bson:"created_time" json:"created_time"`             // 创建时间
+	Mkt           string       `bson:"mkt" json:"mkt"`                               // 市场代码 如：fr-FR
+	Credit        *Credit      `bson:"credit,omitempty" json:"credit,omitempty"`     // 从版权信息解析出的结构化字段
+	Geo           *Geo         `bson:"geo,omitempty" json:"geo,omitempty"`           // 按地点标注的近似坐标
+	OHR           string       `bson:"ohr,omitempty" json:"ohr,omitempty"`           // 图片标识，同一张图片在各市场相同，如 BlueBelize
+	Color         string       `bson:"color,omitempty" json:"color,omitempty"`       // 主色调，如 #1e90ff
+	Palette       []Swatch     `bson:"palette,omitempty" json:"palette,omitempty"`   // 调色板，按占比降序
+	Hashes        *ImageHashes `bson:"hashes,omitempty" json:"hashes,omitempty"`     // 由图片计算的感知哈希
+	BlurHash      string       `bson:"blurhash,omitempty" json:"blurhash,omitempty"` // 图片加载前显示的模糊占位图
+	LQIP          string       `bson:"lqip,omitempty" json:"lqip,omitempty"`         // 极小的 JPEG 预览图，base64 data URI
+}
+
+// ImageHashes 64 位感知哈希，16 位十六进制，汉明距离越小图片越相似
+type ImageHashes struct {
+	AHash string `bson:"ahash" json:"ahash"` // 均值哈希
+	DHash string `bson:"dhash" json:"dhash"` // 差值哈希
+	PHash string `bson:"phash" json:"phash"` // 基于 DCT 的感知哈希，对缩放、压缩和轻微调色最稳定
+}
+
+// Swatch 调色板中的一个颜色，Lab 分量用于按颜色检索
+type Swatch struct {
+	Color      string  `bson:"color" json:"color"`           // #rrggbb
+	Proportion float64 `bson:"proportion" json:"proportion"` // 占图片像素的比例，0-1
+	L          float64 `bson:"l" json:"-"`
+	A          float64 `bson:"a" json:"-"`
+	B          float64 `bson:"b" json:"-"`
+}
+
+// Credit 从版权信息中解析出的主题、地点和署名
+// 如 "Great Blue Hole, Belize (© JamiesOnAMission/Shutterstock)"
+type Credit struct {
+	Subject      string `bson:"subject,omitempty" json:"subject,omitempty"`           // 主题，如 Great Blue Hole
+	Location     string `bson:"location,omitempty" json:"location,omitempty"`         // 国家以外的地点，如 Lelystad
+	Country      string `bson:"country,omitempty" json:"country,omitempty"`           // 原文中的国家名称，如 Belize
+	CountryCode  string `bson:"country_code,omitempty" json:"country_code,omitempty"` // ISO 3166-1 国家代码，如 BZ
+	Photographer string `bson:"photographer,omitempty" json:"photographer,omitempty"` // 摄影师
+	Agency       string `bson:"agency,omitempty" json:"agency,omitempty"`             // 图片库，统一为规范名称，如 Getty Images
 }
 
 // WallpaperResponse API响应结构
@@ -24,8 +67,30 @@ type WallpaperResponse struct {
 	Total  int64       `json:"total"`
 }
 
+// HasResolutionSuffix URL 是否以 _WxH.jpg 结尾，只有这种 URL 才能替换尺寸
+func (w *Wallpaper) HasResolutionSuffix() bool {
+	return resolutionSuffix.MatchString(w.Url)
+}
+
+// ParseOHR 从图片 URL 中提取 OHR 标识，无法识别时返回空字符串
+func ParseOHR(url string) string {
+	match := ohrPattern.FindStringSubmatch(url)
+	if match == nil {
+		return ""
+	}
+	if match[1] != "" {
+		return match[1]
+	}
+	return match[2]
+}
+
 // GenerateImageURL 生成指定尺寸的图片URL
+// URL 不以 _WxH.jpg 结尾时无法替换尺寸，直接返回原始URL
 func (w *Wallpaper) GenerateImageURL(width, height string) string {
+	if !w.HasResolutionSuffix() {
+		return w.Url
+	}
+
 	// 从原始URL中提取基础部分并替换尺寸
 	baseURL := w.Url[:strings.LastIndex(w.Url, "_")+1]
 	return baseURL + width + "x" + height + ".jpg"
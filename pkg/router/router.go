@@ -0,0 +1,54 @@
+package router
+
+import (
+	"log"
+
+	"github.com/gclm/galaxy-bing-wallpapers/pkg/docs"
+	"github.com/gclm/galaxy-bing-wallpapers/pkg/handler"
+	"github.com/gclm/galaxy-bing-wallpapers/pkg/middleware"
+	"github.com/gin-gonic/gin"
+)
+
+// Setup 注册中间件和路由，本地服务与 Vercel 函数共用
+func Setup(r *gin.Engine) {
+	r.Use(middleware.CorsMiddleware())
+	r.Use(middleware.Recovery())
+
+	r.NoRoute(handler.NotFound)
+
+	// 根路径信息
+	r.GET("/", handler.GetInfo)
+
+	// 存活与就绪检查
+	r.GET("/healthz", handler.Liveness)
+	r.GET("/readyz", handler.Readiness)
+
+	// API 文档
+	r.GET("/openapi.json", docs.OpenAPI)
+	r.GET("/docs", docs.Redoc)
+
+	v1 := r.Group("/api/v1")
+	{
+		v1.GET("/today", handler.GetTodayWallpaper)
+		v1.GET("/random", handler.GetRandomWallpaper)
+		v1.GET("/onthisday", handler.GetOnThisDay)
+		v1.GET("/list", middleware.TokenAuth(), handler.GetWallpaperList)
+		v1.GET("/date/:date", middleware.TokenAuth(), handler.GetWallpaperByDate)
+		v1.GET("/calendar/:year/:month", middleware.TokenAuth(), handler.GetCalendar)
+		v1.GET("/archive", middleware.TokenAuth(), handler.GetArchive)
+		v1.GET("/geo", middleware.TokenAuth(), handler.GetWallpaperGeo)
+		v1.GET("/wallpapers/:id/thumb", handler.GetWallpaperThumbnail)
+		v1.GET("/wallpapers/:id/similar", middleware.TokenAuth(), handler.GetSimilarWallpapers)
+		v1.GET("/images/:ohr", middleware.TokenAuth(), handler.GetImageVariants)
+		v1.GET("/stats", middleware.TokenAuth(), handler.GetStats)
+		v1.GET("/markets", handler.GetMarkets)
+		v1.GET("/health", handler.HealthCheck)
+	}
+
+	// 开发模式下检查 OpenAPI 文档是否覆盖所有路由
+	if gin.Mode() == gin.DebugMode {
+		if err := docs.CheckRoutes(r.Routes()); err != nil {
+			log.Printf("Warning: %v", err)
+		}
+	}
+}
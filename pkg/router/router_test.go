@@ -0,0 +1,47 @@
+package router
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gclm/galaxy-bing-wallpapers/pkg/config"
+	"github.com/gclm/galaxy-bing-wallpapers/pkg/docs"
+	"github.com/gin-gonic/gin"
+)
+
+// TestOpenAPIMatchesRoutes openapi.json 必须与注册的路由一一对应
+func TestOpenAPIMatchesRoutes(t *testing.T) {
+	config.GlobalConfig = &config.Config{}
+	gin.SetMode(gin.TestMode)
+
+	r := gin.New()
+	Setup(r)
+
+	if err := docs.CheckRoutes(r.Routes()); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// TestDocsPage /docs 必须返回可用的 Redoc 页面
+func TestDocsPage(t *testing.T) {
+	config.GlobalConfig = &config.Config{}
+	gin.SetMode(gin.TestMode)
+
+	r := gin.New()
+	Setup(r)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/docs", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("GET /docs = %d, want %d: %s", w.Code, http.StatusOK, w.Body.String())
+	}
+	if ct := w.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/html") {
+		t.Errorf("Content-Type = %q, want text/html", ct)
+	}
+	body := w.Body.String()
+	if !strings.Contains(body, `spec-url="openapi.json"`) || !strings.Contains(body, "/redoc@2.1.5/bundles/redoc.standalone.js") {
+		t.Errorf("page does not load the pinned Redoc bundle:\n%s", body)
+	}
+}
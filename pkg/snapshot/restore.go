@@ -0,0 +1,266 @@
+package snapshot
+
+import (
+	"bufio"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/gclm/galaxy-bing-wallpapers/pkg/database"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// restoreBatchSize 每批写入的文档数
+const restoreBatchSize = 500
+
+// namespaceNotFound 集合不存在时 MongoDB 返回的错误码
+const namespaceNotFound = 26
+
+// RestoreOptions 恢复快照的选项
+type RestoreOptions struct {
+	Replace bool // 用快照替换集合；默认合并，已存在的文档（唯一索引冲突）跳过
+	DryRun  bool // 只校验并统计，不写入数据库
+}
+
+// CollectionResult 单个集合的恢复结果
+type CollectionResult struct {
+	Collection string `json:"collection"`
+	Documents  int    `json:"documents"`
+	Inserted   int    `json:"inserted"`
+	Skipped    int    `json:"skipped"`
+}
+
+// Verify 校验快照中所有文件的大小和 SHA-256，以及每个集合文件的文档数
+func Verify(dir string, m *Manifest) error {
+	for _, f := range m.Files {
+		path := filepath.Join(dir, f.Path)
+		ok, err := verifyFile(path, f.Size, f.SHA256)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return fmt.Errorf("checksum mismatch for %s", f.Path)
+		}
+
+		count := 0
+		if err := readDocuments(path, func(bson.Raw) error { count++; return nil }); err != nil {
+			return err
+		}
+		if count != f.Count {
+			return fmt.Errorf("%s contains %d documents, manifest says %d", f.Path, count, f.Count)
+		}
+	}
+
+	for _, image := range m.Images {
+		ok, err := verifyFile(filepath.Join(dir, filepath.FromSlash(image.Path)), image.Size, image.SHA256)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return fmt.Errorf("checksum mismatch for %s", image.Path)
+		}
+	}
+	return nil
+}
+
+// Restore 将快照中的集合写入数据库，调用前应先通过 Verify 校验
+// Replace 时先把每个集合写入临时集合，全部写完后再逐个重命名替换原集合，写入失败时原集合保持不变
+func Restore(ctx context.Context, dir string, m *Manifest, opts RestoreOptions) ([]CollectionResult, error) {
+	var results []CollectionResult
+	var staged []string
+	defer func() {
+		// 没有替换成功的临时集合
+		for _, name := range staged {
+			database.GetCollection(stagingName(name)).Drop(context.WithoutCancel(ctx))
+		}
+	}()
+
+	for _, f := range m.Files {
+		result := CollectionResult{Collection: f.Collection, Documents: f.Count}
+		collection := database.GetCollection(f.Collection)
+
+		if opts.Replace && !opts.DryRun {
+			stage, err := stageCollection(ctx, f.Collection)
+			if err != nil {
+				return results, err
+			}
+			staged = append(staged, f.Collection)
+			collection = stage
+		}
+
+		batch := make([]interface{}, 0, restoreBatchSize)
+		flush := func() error {
+			if len(batch) == 0 || opts.DryRun {
+				batch = batch[:0]
+				return nil
+			}
+			inserted, skipped, err := insertBatch(ctx, collection, batch)
+			result.Inserted += inserted
+			result.Skipped += skipped
+			batch = batch[:0]
+			return err
+		}
+
+		err := readDocuments(filepath.Join(dir, f.Path), func(doc bson.Raw) error {
+			batch = append(batch, doc)
+			if len(batch) >= restoreBatchSize {
+				return flush()
+			}
+			return nil
+		})
+		if err == nil {
+			err = flush()
+		}
+		results = append(results, result)
+		if err != nil {
+			return results, fmt.Errorf("failed to restore %s: %v", f.Collection, err)
+		}
+	}
+	if opts.DryRun {
+		return results, nil
+	}
+
+	for len(staged) > 0 {
+		if err := replaceCollection(ctx, staged[0]); err != nil {
+			return results, err
+		}
+		staged = staged[1:]
+	}
+	return results, database.BumpDataVersion(ctx)
+}
+
+// stagingName 替换恢复时使用的临时集合
+func stagingName(name string) string {
+	return name + "_restore"
+}
+
+// stageCollection 创建空的临时集合，并复制原集合的索引，使唯一索引在写入时同样生效、替换后索引保持不变
+func stageCollection(ctx context.Context, name string) (*mongo.Collection, error) {
+	stage := database.GetCollection(stagingName(name))
+	// 上次中断的恢复可能留下临时集合
+	if err := stage.Drop(ctx); err != nil {
+		return nil, fmt.Errorf("failed to drop %s: %v", stage.Name(), err)
+	}
+
+	cursor, err := database.GetCollection(name).Indexes().List(ctx)
+	var cmdErr mongo.CommandError
+	if errors.As(err, &cmdErr) && cmdErr.Code == namespaceNotFound {
+		return stage, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to list %s indexes: %v", name, err)
+	}
+	var specs []bson.M
+	if err := cursor.All(ctx, &specs); err != nil {
+		return nil, fmt.Errorf("failed to decode %s indexes: %v", name, err)
+	}
+
+	indexes := bson.A{}
+	for _, spec := range specs {
+		if spec["name"] == "_id_" {
+			continue
+		}
+		delete(spec, "v")
+		delete(spec, "ns")
+		indexes = append(indexes, spec)
+	}
+	// 没有其他索引时也创建集合，重命名时才有来源集合
+	command := bson.D{{Key: "create", Value: stage.Name()}}
+	if len(indexes) > 0 {
+		command = bson.D{{Key: "createIndexes", Value: stage.Name()}, {Key: "indexes", Value: indexes}}
+	}
+	if err := stage.Database().RunCommand(ctx, command).Err(); err != nil {
+		return nil, fmt.Errorf("failed to create %s: %v", stage.Name(), err)
+	}
+	return stage, nil
+}
+
+// replaceCollection 将临时集合重命名为原集合，原集合在同一操作中删除
+func replaceCollection(ctx context.Context, name string) error {
+	stage := database.GetCollection(stagingName(name))
+	db := stage.Database().Name()
+	err := stage.Database().Client().Database("admin").RunCommand(ctx, bson.D{
+		{Key: "renameCollection", Value: db + "." + stage.Name()},
+		{Key: "to", Value: db + "." + name},
+		{Key: "dropTarget", Value: true},
+	}).Err()
+	if err != nil {
+		return fmt.Errorf("failed to replace %s: %v", name, err)
+	}
+	return nil
+}
+
+// insertBatch 无序批量插入，唯一索引冲突的文档计为跳过
+func insertBatch(ctx context.Context, collection *mongo.Collection, docs []interface{}) (int, int, error) {
+	result, err := collection.InsertMany(ctx, docs, options.InsertMany().SetOrdered(false))
+	if err == nil {
+		return len(result.InsertedIDs), 0, nil
+	}
+
+	bulkErr, ok := err.(mongo.BulkWriteException)
+	if !ok || bulkErr.WriteConcernError != nil {
+		return 0, 0, err
+	}
+	for _, writeErr := range bulkErr.WriteErrors {
+		if writeErr.Code != 11000 {
+			return 0, 0, err
+		}
+	}
+	skipped := len(bulkErr.WriteErrors)
+	return len(docs) - skipped, skipped, nil
+}
+
+// readDocuments 逐行读取 gzip 压缩的 Extended JSON 文档
+func readDocuments(path string, fn func(bson.Raw) error) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to open %s: %v", path, err)
+	}
+	defer file.Close()
+
+	gz, err := gzip.NewReader(file)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %v", path, err)
+	}
+	defer gz.Close()
+
+	scanner := bufio.NewScanner(gz)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		var doc bson.Raw
+		if err := bson.UnmarshalExtJSON(scanner.Bytes(), true, &doc); err != nil {
+			return fmt.Errorf("%s line %d: %v", filepath.Base(path), line, err)
+		}
+		if err := fn(doc); err != nil {
+			return err
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("failed to read %s: %v", path, err)
+	}
+	return nil
+}
+
+// verifyFile 检查文件大小和 SHA-256 是否与清单一致
+func verifyFile(path string, size int64, sum string) (bool, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return false, fmt.Errorf("failed to open %s: %v", path, err)
+	}
+	defer file.Close()
+
+	hash := sha256.New()
+	n, err := io.Copy(hash, file)
+	if err != nil {
+		return false, fmt.Errorf("failed to read %s: %v", path, err)
+	}
+	return n == size && hex.EncodeToString(hash.Sum(nil)) == sum, nil
+}
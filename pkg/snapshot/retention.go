@@ -0,0 +1,106 @@
+package snapshot
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Snapshot 快照目录及其清单
+type Snapshot struct {
+	Path     string
+	Manifest *Manifest
+}
+
+// List 列出根目录中的完整快照（有可读清单的 snapshot-* 目录），按创建时间升序
+func List(root string) ([]Snapshot, error) {
+	entries, err := os.ReadDir(root)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read snapshot directory: %v", err)
+	}
+
+	var snapshots []Snapshot
+	for _, entry := range entries {
+		if !entry.IsDir() || !strings.HasPrefix(entry.Name(), namePrefix) || strings.HasSuffix(entry.Name(), ".tmp") {
+			continue
+		}
+		path := filepath.Join(root, entry.Name())
+		m, err := ReadManifest(path)
+		if err != nil {
+			continue
+		}
+		snapshots = append(snapshots, Snapshot{Path: path, Manifest: m})
+	}
+
+	sort.Slice(snapshots, func(i, j int) bool {
+		return snapshots[i].Manifest.CreatedAt.Before(snapshots[j].Manifest.CreatedAt)
+	})
+	return snapshots, nil
+}
+
+// LatestImageDir 根目录中最新的带有镜像图片的完整快照目录
+// 只检查目录名、清单文件和 images 目录是否存在，不读取清单，适合在请求中调用
+func LatestImageDir(root string) (string, bool) {
+	entries, err := os.ReadDir(root)
+	if err != nil {
+		return "", false
+	}
+
+	// 目录名中的时间格式固定，按名称倒序即从新到旧
+	for i := len(entries) - 1; i >= 0; i-- {
+		name := entries[i].Name()
+		if !entries[i].IsDir() || !strings.HasPrefix(name, namePrefix) || strings.HasSuffix(name, ".tmp") {
+			continue
+		}
+		dir := filepath.Join(root, name)
+		if _, err := os.Stat(filepath.Join(dir, ManifestName)); err != nil {
+			continue
+		}
+		if info, err := os.Stat(filepath.Join(dir, "images")); err == nil && info.IsDir() {
+			return dir, true
+		}
+	}
+	return "", false
+}
+
+// FindImage 在快照目录中查找镜像的壁纸图片
+// 备份时未变化的图片会链接到新快照，所以只需查找最新的快照
+func FindImage(dir, mkt, datetime string) (string, bool) {
+	path := filepath.Join(dir, filepath.FromSlash(imagePath(mkt, datetime)))
+	if info, err := os.Stat(path); err == nil && info.Mode().IsRegular() {
+		return path, true
+	}
+	return "", false
+}
+
+// Prune 按保留策略删除旧快照：只保留最新的 keep 个，并删除早于 maxAge 的快照
+// keep 或 maxAge 为 0 时不限制对应条件，最新的快照始终保留
+func Prune(root string, keep int, maxAge time.Duration) ([]string, error) {
+	snapshots, err := List(root)
+	if err != nil {
+		return nil, err
+	}
+
+	var removed []string
+	cutoff := time.Now().Add(-maxAge)
+	for i, s := range snapshots {
+		newer := len(snapshots) - 1 - i // 比它新的快照数量
+		if newer == 0 {
+			break
+		}
+		tooMany := keep > 0 && newer >= keep
+		tooOld := maxAge > 0 && s.Manifest.CreatedAt.Before(cutoff)
+		if !tooMany && !tooOld {
+			continue
+		}
+
+		if err := os.RemoveAll(s.Path); err != nil {
+			return removed, fmt.Errorf("failed to remove snapshot %s: %v", s.Path, err)
+		}
+		removed = append(removed, s.Path)
+	}
+	return removed, nil
+}
@@ -0,0 +1,295 @@
+package snapshot
+
+import (
+	"bufio"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/gclm/galaxy-bing-wallpapers/pkg/client"
+	"github.com/gclm/galaxy-bing-wallpapers/pkg/database"
+	"github.com/gclm/galaxy-bing-wallpapers/pkg/version"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	// FormatVersion 快照格式版本
+	FormatVersion = 1
+	// ManifestName 快照目录中的清单文件
+	ManifestName = "manifest.json"
+	// namePrefix 快照目录名前缀，后接 UTC 时间，如 snapshot-20250219T080000Z
+	namePrefix = "snapshot-"
+	nameLayout = "20060102T150405Z"
+)
+
+// Collections 快照包含的集合
+var Collections = []string{"wallpapers", "fetch_logs", "schema_migrations"}
+
+// Manifest 快照清单，记录每个文件的大小和 SHA-256
+type Manifest struct {
+	Format     int          `json:"format"`
+	Name       string       `json:"name"`
+	CreatedAt  time.Time    `json:"created_at"`
+	AppVersion string       `json:"app_version"`
+	Files      []FileEntry  `json:"files"`
+	Images     []ImageEntry `json:"images,omitempty"`
+}
+
+// FileEntry 单个集合的导出文件（gzip 压缩的 NDJSON，每行一个 Extended JSON 文档）
+type FileEntry struct {
+	Collection string `json:"collection"`
+	Path       string `json:"path"`
+	Count      int    `json:"count"`
+	Size       int64  `json:"size"`
+	SHA256     string `json:"sha256"`
+}
+
+// ImageEntry 镜像的壁纸图片
+type ImageEntry struct {
+	Mkt      string `json:"mkt"`
+	Datetime string `json:"datetime"`
+	URL      string `json:"url"`
+	Path     string `json:"path"`
+	Size     int64  `json:"size"`
+	SHA256   string `json:"sha256"`
+}
+
+// Options 创建快照的选项
+type Options struct {
+	Dir    string // 快照根目录，快照写入其中的 snapshot-<时间> 子目录
+	Images bool   // 是否同时镜像壁纸图片
+}
+
+// Create 创建快照：先写入临时目录，全部完成后再重命名，中断时不会留下不完整的快照
+// 镜像图片时会复用上一个快照中相同地址的图片
+func Create(ctx context.Context, opts Options) (*Manifest, string, error) {
+	now := time.Now().UTC()
+	m := &Manifest{
+		Format:     FormatVersion,
+		Name:       namePrefix + now.Format(nameLayout),
+		CreatedAt:  now,
+		AppVersion: version.Version,
+	}
+
+	path := filepath.Join(opts.Dir, m.Name)
+	tmpPath := path + ".tmp"
+	if err := os.MkdirAll(tmpPath, 0o755); err != nil {
+		return nil, "", fmt.Errorf("failed to create snapshot directory: %v", err)
+	}
+	defer os.RemoveAll(tmpPath)
+
+	for _, name := range Collections {
+		entry, err := dumpCollection(ctx, tmpPath, name)
+		if err != nil {
+			return nil, "", err
+		}
+		m.Files = append(m.Files, *entry)
+		log.Printf("Dumped %s: %d documents", name, entry.Count)
+	}
+
+	if opts.Images {
+		images, err := mirrorImages(ctx, opts.Dir, tmpPath)
+		if err != nil {
+			return nil, "", err
+		}
+		m.Images = images
+	}
+
+	if err := writeManifest(tmpPath, m); err != nil {
+		return nil, "", err
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		return nil, "", fmt.Errorf("failed to finalize snapshot: %v", err)
+	}
+	return m, path, nil
+}
+
+// dumpCollection 将集合导出为 gzip 压缩的 NDJSON
+func dumpCollection(ctx context.Context, dir, name string) (*FileEntry, error) {
+	entry := &FileEntry{Collection: name, Path: name + ".ndjson.gz"}
+
+	file, err := os.Create(filepath.Join(dir, entry.Path))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create %s: %v", entry.Path, err)
+	}
+	defer file.Close()
+
+	hash := sha256.New()
+	counter := &countingWriter{w: io.MultiWriter(file, hash)}
+	gz := gzip.NewWriter(counter)
+	buf := bufio.NewWriter(gz)
+
+	cursor, err := database.GetCollection(name).Find(ctx, bson.M{}, options.Find().SetSort(bson.D{{Key: "_id", Value: 1}}))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %v", name, err)
+	}
+	defer cursor.Close(ctx)
+
+	for cursor.Next(ctx) {
+		line, err := bson.MarshalExtJSON(cursor.Current, true, false)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode %s document: %v", name, err)
+		}
+		buf.Write(line)
+		buf.WriteByte('\n')
+		entry.Count++
+	}
+	if err := cursor.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read %s: %v", name, err)
+	}
+
+	if err := buf.Flush(); err != nil {
+		return nil, fmt.Errorf("failed to write %s: %v", entry.Path, err)
+	}
+	if err := gz.Close(); err != nil {
+		return nil, fmt.Errorf("failed to write %s: %v", entry.Path, err)
+	}
+	if err := file.Sync(); err != nil {
+		return nil, fmt.Errorf("failed to write %s: %v", entry.Path, err)
+	}
+
+	entry.Size = counter.n
+	entry.SHA256 = hex.EncodeToString(hash.Sum(nil))
+	return entry, nil
+}
+
+// mirrorImages 下载所有壁纸图片到快照的 images/<mkt>/<datetime>.jpg
+// 上一个快照中已有相同地址且校验通过的图片直接硬链接（失败时复制），下载失败的图片只记录日志
+func mirrorImages(ctx context.Context, root, dir string) ([]ImageEntry, error) {
+	wallpapers, err := database.ListWallpapers(ctx, bson.M{}, bson.D{{Key: "mkt", Value: 1}, {Key: "datetime", Value: 1}})
+	if err != nil {
+		return nil, err
+	}
+
+	previous := make(map[string]ImageEntry)
+	var previousDir string
+	if snapshots, err := List(root); err == nil && len(snapshots) > 0 {
+		latest := snapshots[len(snapshots)-1]
+		previousDir = latest.Path
+		for _, image := range latest.Manifest.Images {
+			previous[image.URL] = image
+		}
+	}
+
+	downloader := client.New("", "")
+	var images []ImageEntry
+	var failed int
+	for _, w := range wallpapers {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+
+		entry := ImageEntry{
+			Mkt:      w.Mkt,
+			Datetime: w.Datetime,
+			URL:      w.Url,
+			Path:     imagePath(w.Mkt, w.Datetime),
+		}
+		target := filepath.Join(dir, entry.Path)
+		if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+			return nil, fmt.Errorf("failed to create image directory: %v", err)
+		}
+
+		if prev, ok := previous[w.Url]; ok && reuseImage(filepath.Join(previousDir, prev.Path), target, prev) {
+			entry.Size, entry.SHA256 = prev.Size, prev.SHA256
+			images = append(images, entry)
+			continue
+		}
+
+		result, err := downloader.Download(ctx, w.GenerateImageURL("1920", "1080"), target)
+		if err != nil {
+			failed++
+			log.Printf("Failed to mirror image %s %s: %v", w.Mkt, w.Datetime, err)
+			continue
+		}
+		entry.Size, entry.SHA256 = result.Size, result.SHA256
+		images = append(images, entry)
+	}
+
+	log.Printf("Mirrored %d images (%d failed)", len(images), failed)
+	return images, nil
+}
+
+// imagePath 镜像图片在快照中的相对路径
+func imagePath(mkt, datetime string) string {
+	return filepath.ToSlash(filepath.Join("images", mkt, datetime+".jpg"))
+}
+
+// reuseImage 校验上一个快照中的图片，通过后链接到新快照
+func reuseImage(src, dst string, prev ImageEntry) bool {
+	if ok, _ := verifyFile(src, prev.Size, prev.SHA256); !ok {
+		return false
+	}
+	if err := os.Link(src, dst); err == nil {
+		return true
+	}
+	return copyFile(src, dst) == nil
+}
+
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+// writeManifest 写入快照清单
+func writeManifest(dir string, m *Manifest) error {
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode manifest: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, ManifestName), data, 0o644); err != nil {
+		return fmt.Errorf("failed to write manifest: %v", err)
+	}
+	return nil
+}
+
+// ReadManifest 读取快照目录中的清单
+func ReadManifest(dir string) (*Manifest, error) {
+	data, err := os.ReadFile(filepath.Join(dir, ManifestName))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read manifest: %v", err)
+	}
+
+	var m Manifest
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, fmt.Errorf("failed to parse manifest: %v", err)
+	}
+	if m.Format != FormatVersion {
+		return nil, fmt.Errorf("unsupported snapshot format %d", m.Format)
+	}
+	return &m, nil
+}
+
+// countingWriter 统计写入的字节数
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
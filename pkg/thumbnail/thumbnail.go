@@ -0,0 +1,177 @@
+// Package thumbnail 按配置的尺寸生成壁纸缩略图，缓存在本地目录
+//
+// 原图优先使用 cmd/backup --images 镜像在快照中的图片，没有镜像时从必应下载。
+// 同一张图片（OHR 标识相同）的各市场版本共用缓存。同一张缩略图的并发请求只生成一次，
+// 同时生成的缩略图数量有上限，超出时排队等待。
+package thumbnail
+
+import (
+	"context"
+	"fmt"
+	"image"
+	"image/jpeg"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gclm/galaxy-bing-wallpapers/pkg/imaging"
+	"github.com/gclm/galaxy-bing-wallpapers/pkg/model"
+	"github.com/gclm/galaxy-bing-wallpapers/pkg/snapshot"
+	"golang.org/x/sync/semaphore"
+	"golang.org/x/sync/singleflight"
+)
+
+const (
+	// maxWidth、maxHeight 缩略图尺寸的上限，不超过镜像原图的尺寸
+	maxWidth  = 1920
+	maxHeight = 1080
+	// quality 缩略图的 JPEG 质量
+	quality = 85
+	// mirrorRefresh 重新查找最新镜像快照的间隔，备份通常每天一次
+	mirrorRefresh = time.Minute
+)
+
+// Size 缩略图尺寸
+type Size struct {
+	Width  int
+	Height int
+}
+
+// ParseSize 解析 WIDTHxHEIGHT 格式的尺寸，如 320x180
+func ParseSize(value string) (Size, error) {
+	w, h, ok := strings.Cut(strings.TrimSpace(value), "x")
+	width, errW := strconv.Atoi(w)
+	height, errH := strconv.Atoi(h)
+	if !ok || errW != nil || errH != nil {
+		return Size{}, fmt.Errorf("invalid thumbnail size %q, expected WIDTHxHEIGHT", value)
+	}
+	if width < 1 || width > maxWidth || height < 1 || height > maxHeight {
+		return Size{}, fmt.Errorf("thumbnail size %q out of range, at most %dx%d", value, maxWidth, maxHeight)
+	}
+	return Size{Width: width, Height: height}, nil
+}
+
+func (s Size) String() string {
+	return strconv.Itoa(s.Width) + "x" + strconv.Itoa(s.Height)
+}
+
+// Store 缩略图缓存
+type Store struct {
+	dir    string // 缓存目录，按 <尺寸>/<图片>.jpg 存放
+	mirror string // 快照根目录，为空时不使用镜像
+
+	group singleflight.Group  // 按缓存路径合并同一张缩略图的并发生成
+	slots *semaphore.Weighted // 限制同时生成的数量，解码原图和缩放都很占 CPU 和内存
+
+	mu         sync.Mutex
+	latest     string    // 最新的带镜像图片的快照目录
+	latestTime time.Time // 上次查找 latest 的时间
+}
+
+// New 创建缩略图缓存，mirror 为 cmd/backup 的快照根目录，可为空
+// 同时生成的缩略图数量不超过 CPU 核数
+func New(dir, mirror string) *Store {
+	return &Store{dir: dir, mirror: mirror, slots: semaphore.NewWeighted(int64(runtime.NumCPU()))}
+}
+
+// Path 返回缩略图文件的路径，缓存中没有时生成
+// 同一张缩略图的并发请求共用一次生成；等待生成名额时 ctx 结束则返回错误
+func (s *Store) Path(ctx context.Context, wallpaper model.Wallpaper, size Size) (string, error) {
+	path := filepath.Join(s.dir, size.String(), cacheName(wallpaper))
+	if _, err := os.Stat(path); err == nil {
+		return path, nil
+	}
+
+	_, err, _ := s.group.Do(path, func() (interface{}, error) {
+		// 排队期间可能已由其他请求生成
+		if _, err := os.Stat(path); err == nil {
+			return nil, nil
+		}
+		if err := s.slots.Acquire(ctx, 1); err != nil {
+			return nil, fmt.Errorf("timed out waiting to generate thumbnail: %v", err)
+		}
+		defer s.slots.Release(1)
+
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			return nil, fmt.Errorf("failed to create thumbnail directory: %v", err)
+		}
+		return nil, s.generate(ctx, wallpaper, size, path)
+	})
+	if err != nil {
+		return "", err
+	}
+	return path, nil
+}
+
+// generate 读取原图，裁切缩放后写入 path
+func (s *Store) generate(ctx context.Context, wallpaper model.Wallpaper, size Size, path string) error {
+	img, err := s.original(ctx, wallpaper)
+	if err != nil {
+		return err
+	}
+	thumb := imaging.Cover(img, size.Width, size.Height)
+
+	file, err := os.CreateTemp(filepath.Dir(path), ".thumb-*")
+	if err != nil {
+		return fmt.Errorf("failed to create thumbnail: %v", err)
+	}
+	err = jpeg.Encode(file, thumb, &jpeg.Options{Quality: quality})
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(file.Name(), path)
+	}
+	if err != nil {
+		os.Remove(file.Name())
+		return fmt.Errorf("failed to write thumbnail: %v", err)
+	}
+	return nil
+}
+
+// original 读取原图，镜像中没有或无法解码时从必应下载 1920x1080 的版本
+func (s *Store) original(ctx context.Context, wallpaper model.Wallpaper) (image.Image, error) {
+	if dir, ok := s.latestMirror(); ok {
+		if path, ok := snapshot.FindImage(dir, wallpaper.Mkt, wallpaper.Datetime); ok {
+			if img, err := decodeFile(path); err == nil {
+				return img, nil
+			}
+		}
+	}
+	return imaging.Download(ctx, wallpaper.GenerateImageURL("1920", "1080"))
+}
+
+// latestMirror 最新的镜像快照目录，每隔 mirrorRefresh 重新查找一次，避免每次都遍历所有快照
+func (s *Store) latestMirror() (string, bool) {
+	if s.mirror == "" {
+		return "", false
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if time.Since(s.latestTime) >= mirrorRefresh {
+		s.latest, _ = snapshot.LatestImageDir(s.mirror)
+		s.latestTime = time.Now()
+	}
+	return s.latest, s.latest != ""
+}
+
+func decodeFile(path string) (image.Image, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return imaging.Decode(file)
+}
+
+// cacheName 缓存文件名，有图片标识时各市场共用
+func cacheName(wallpaper model.Wallpaper) string {
+	if wallpaper.OHR != "" {
+		return wallpaper.OHR + ".jpg"
+	}
+	return wallpaper.Mkt + "_" + wallpaper.Datetime + ".jpg"
+}
@@ -0,0 +1,136 @@
+package thumbnail
+
+import (
+	"context"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/gclm/galaxy-bing-wallpapers/pkg/model"
+	"github.com/gclm/galaxy-bing-wallpapers/pkg/snapshot"
+)
+
+// writeSnapshot 创建一个快照目录，images 为 nil 时不创建 images 目录
+func writeSnapshot(t *testing.T, root, name string, manifest bool, images map[string]image.Image) string {
+	t.Helper()
+	dir := filepath.Join(root, name)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if manifest {
+		if err := os.WriteFile(filepath.Join(dir, snapshot.ManifestName), []byte("{}"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for rel, img := range images {
+		path := filepath.Join(dir, "images", filepath.FromSlash(rel))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		file, err := os.Create(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := jpeg.Encode(file, img, nil); err != nil {
+			t.Fatal(err)
+		}
+		file.Close()
+	}
+	return dir
+}
+
+func solid(c color.Color) image.Image {
+	img := image.NewRGBA(image.Rect(0, 0, 192, 108))
+	for y := 0; y < 108; y++ {
+		for x := 0; x < 192; x++ {
+			img.Set(x, y, c)
+		}
+	}
+	return img
+}
+
+func TestLatestImageDir(t *testing.T) {
+	root := t.TempDir()
+	img := map[string]image.Image{"zh-CN/2024-01-01.jpg": solid(color.White)}
+	want := writeSnapshot(t, root, "snapshot-20240101T000000Z", true, img)
+	writeSnapshot(t, root, "snapshot-20240102T000000Z", true, nil)  // 没有镜像图片
+	writeSnapshot(t, root, "snapshot-20240103T000000Z", false, img) // 没有清单，未完成
+	writeSnapshot(t, root, "snapshot-20240104T000000Z.tmp", true, img)
+
+	got, ok := snapshot.LatestImageDir(root)
+	if !ok || got != want {
+		t.Fatalf("LatestImageDir = %q, %v; want %q", got, ok, want)
+	}
+	if _, ok := snapshot.FindImage(got, "zh-CN", "2024-01-01"); !ok {
+		t.Error("FindImage did not find mirrored image")
+	}
+	if _, ok := snapshot.FindImage(got, "zh-CN", "2024-01-02"); ok {
+		t.Error("FindImage found missing image")
+	}
+}
+
+func TestPathConcurrent(t *testing.T) {
+	mirror := t.TempDir()
+	writeSnapshot(t, mirror, "snapshot-20240101T000000Z", true, map[string]image.Image{
+		"zh-CN/2024-01-01.jpg": solid(color.RGBA{R: 200, A: 255}),
+	})
+	cache := t.TempDir()
+	store := New(cache, mirror)
+	wallpaper := model.Wallpaper{Mkt: "zh-CN", Datetime: "2024-01-01", OHR: "Test"}
+	size := Size{Width: 64, Height: 36}
+
+	var wg sync.WaitGroup
+	paths := make([]string, 16)
+	errs := make([]error, len(paths))
+	for i := range paths {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			paths[i], errs[i] = store.Path(context.Background(), wallpaper, size)
+		}(i)
+	}
+	wg.Wait()
+
+	for i := range paths {
+		if errs[i] != nil {
+			t.Fatal(errs[i])
+		}
+		if paths[i] != paths[0] {
+			t.Fatalf("got different paths %q and %q", paths[i], paths[0])
+		}
+	}
+
+	file, err := os.Open(paths[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	cfg, err := jpeg.DecodeConfig(file)
+	if err != nil || cfg.Width != size.Width || cfg.Height != size.Height {
+		t.Fatalf("thumbnail is %dx%d, %v; want %v", cfg.Width, cfg.Height, err, size)
+	}
+
+	entries, _ := os.ReadDir(filepath.Dir(paths[0]))
+	for _, entry := range entries {
+		if strings.HasPrefix(entry.Name(), ".thumb-") {
+			t.Errorf("temporary file left behind: %s", entry.Name())
+		}
+	}
+}
+
+func TestPathWaitsForSlot(t *testing.T) {
+	store := New(t.TempDir(), "")
+	// 占满所有生成名额
+	for store.slots.TryAcquire(1) {
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := store.Path(ctx, model.Wallpaper{Mkt: "zh-CN", Datetime: "2024-01-01"}, Size{Width: 32, Height: 18}); err == nil {
+		t.Fatal("expected error when no generation slot is available")
+	}
+}
@@ -10,6 +10,7 @@ import (
 	"time"
 
 	"github.com/gclm/galaxy-bing-wallpapers/pkg/database"
+	"github.com/gclm/galaxy-bing-wallpapers/pkg/imaging"
 	"github.com/gclm/galaxy-bing-wallpapers/pkg/model"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/mongo"
@@ -18,17 +19,65 @@ import (
 
 const (
 	bingAPIURL = "https://www.bing.com/HPImageArchive.aspx?format=js&idx=0&n=1&mkt=%s"
+	// bingArchiveURL 必应最多返回 8 张，idx 最大为 7，两次请求可以覆盖最近约 15 天
+	bingArchiveURL = "https://www.bing.com/HPImageArchive.aspx?format=js&idx=%d&n=8&mkt=%s"
 )
 
 type BingResponse struct {
-	Images []struct {
-		URL          string `json:"url"`
-		Title        string `json:"title"`
-		Copyright    string `json:"copyright"`
-		CopyrightURL string `json:"copyrightlink"`
-		StartDate    string `json:"startdate"`
-		Hsh          string `json:"hsh"`
-	} `json:"images"`
+	Images []BingImage `json:"images"`
+}
+
+// BingImage 必应接口返回的单张壁纸
+type BingImage struct {
+	URL          string `json:"url"`
+	Title        string `json:"title"`
+	Copyright    string `json:"copyright"`
+	CopyrightURL string `json:"copyrightlink"`
+	StartDate    string `json:"startdate"`
+	Hsh          string `json:"hsh"`
+}
+
+// Date 将 startdate（YYYYMMDD，市场当地日期）转换为 YYYY-MM-DD
+func (image BingImage) Date() (string, bool) {
+	date, err := time.Parse("20060102", image.StartDate)
+	if err != nil {
+		return "", false
+	}
+	return date.Format("2006-01-02"), true
+}
+
+// FetchRecentImages 获取必应接口中该市场最近约 15 天的壁纸，按 startdate 去重
+func FetchRecentImages(ctx context.Context, mkt string) ([]BingImage, error) {
+	var images []BingImage
+	seen := make(map[string]bool)
+	for _, idx := range []int{0, 7} {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, fmt.Sprintf(bingArchiveURL, idx, mkt), nil)
+		if err != nil {
+			return nil, err
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch Bing API: %v", err)
+		}
+		var bingResp BingResponse
+		if resp.StatusCode != http.StatusOK {
+			err = fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+		} else if err = json.NewDecoder(resp.Body).Decode(&bingResp); err != nil {
+			err = fmt.Errorf("failed to parse JSON response: %v", err)
+		}
+		resp.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+
+		for _, image := range bingResp.Images {
+			if !seen[image.StartDate] {
+				seen[image.StartDate] = true
+				images = append(images, image)
+			}
+		}
+	}
+	return images, nil
 }
 
 // FetchLatestWallpaper 获取最新壁纸
@@ -83,7 +132,7 @@ func FetchLatestWallpaper(mkt string) (bool, error) {
 	wallpaper := model.Wallpaper{
 		Title:         image.Title,
 		Url:           "https://www.bing.com" + image.URL,
-		Datetime:      time.Now().Format("2006-01-02"),
+		Datetime:      imageDate(image, mkt),
 		Copyright:     image.Copyright,
 		CopyrightLink: image.CopyrightURL,
 		Hsh:           image.Hsh,
@@ -103,6 +152,11 @@ func FetchLatestWallpaper(mkt string) (bool, error) {
 		return false, nil
 	}
 
+	// 计算主色调等图片特征，失败时照常保存，之后由 cmd/backfill 补全
+	if err := imaging.AnnotateURL(context.Background(), &wallpaper); err != nil {
+		log.Printf("⚠️ 图片分析失败: %v", err)
+	}
+
 	// 保存到数据库
 	if err := database.SaveWallpaper(wallpaper); err != nil {
 		log.Printf("❌ 保存壁纸失败: %v", err)
@@ -113,6 +167,15 @@ func FetchLatestWallpaper(mkt string) (bool, error) {
 	return true, nil
 }
 
+// imageDate 壁纸的日期，即必应返回的 startdate
+// 无法解析时使用市场所在时区的当前日期，而不是服务器所在时区
+func imageDate(image BingImage, mkt string) string {
+	if date, ok := image.Date(); ok {
+		return date
+	}
+	return time.Now().In(model.MarketLocation(mkt)).Format("2006-01-02")
+}
+
 // SaveWallpaper 保存壁纸信息到数据库
 func SaveWallpaper(wallpaper model.Wallpaper) error {
 	collection := database.GetCollection("wallpapers")
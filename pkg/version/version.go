@@ -0,0 +1,32 @@
+package version
+
+import "runtime"
+
+// 构建信息，编译时通过 -ldflags 注入：
+//
+//	go build -ldflags "-X github.com/gclm/galaxy-bing-wallpapers/pkg/version.Version=1.1.0 \
+//	  -X github.com/gclm/galaxy-bing-wallpapers/pkg/version.Commit=$(git rev-parse --short HEAD) \
+//	  -X github.com/gclm/galaxy-bing-wallpapers/pkg/version.BuildTime=$(date -u +%Y-%m-%dT%H:%M:%SZ)"
+var (
+	Version   = "1.0.0"
+	Commit    = "unknown"
+	BuildTime = "unknown"
+)
+
+// Info 构建信息
+type Info struct {
+	Version   string `json:"version"`    // 版本号
+	Commit    string `json:"commit"`     // Git 提交
+	BuildTime string `json:"build_time"` // 构建时间
+	GoVersion string `json:"go_version"` // Go 版本
+}
+
+// Get 获取构建信息
+func Get() Info {
+	return Info{
+		Version:   Version,
+		Commit:    Commit,
+		BuildTime: BuildTime,
+		GoVersion: runtime.Version(),
+	}
+}